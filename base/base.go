@@ -5,15 +5,19 @@ import (
 	"fmt"
 	"os"
 	"reflect"
-	"time"
 
+	"github.com/MuradIsayev/todo-tracker/clock"
 	"github.com/MuradIsayev/todo-tracker/helpers"
+	"github.com/MuradIsayev/todo-tracker/search"
 	"github.com/MuradIsayev/todo-tracker/status"
 )
 
 // BaseService is a base service for all services (ProjectService, TaskService)
 type BaseService[T any] struct {
 	FilePath string
+	Clock    clock.Clock
+	// Search index kept up to date with the items written to the file (optional)
+	Index *search.Index
 }
 
 // Reads the data from the file
@@ -45,9 +49,25 @@ func (s *BaseService[T]) WriteToFile(data []T) error {
 		return fmt.Errorf("cannot write to file: %v", err)
 	}
 
+	if s.Index != nil {
+		return s.Index.Update(s.FilePath, s.SearchDocuments(data))
+	}
+
 	return nil
 }
 
+// Returns the search documents of the items that are indexed
+func (s *BaseService[T]) SearchDocuments(items []T) []search.Document {
+	documents := []search.Document{}
+	for _, item := range items {
+		if indexable, ok := any(item).(search.Indexable); ok {
+			documents = append(documents, indexable.SearchDocument())
+		}
+	}
+
+	return documents
+}
+
 // Gets the next ID for the item (Project or Task)
 func (s *BaseService[T]) GetNextID(items []T) int {
 	v := reflect.ValueOf(items)
@@ -104,7 +124,7 @@ func (s *BaseService[T]) UpdateItemName(id, name string) error {
 		v.SetString(name)
 
 		v = reflect.ValueOf(item).Elem().FieldByName("UpdatedAt")
-		v.Set(reflect.ValueOf(time.Now()))
+		v.Set(reflect.ValueOf(s.Clock.Now()))
 
 		items[index] = *item
 	}
@@ -112,6 +132,34 @@ func (s *BaseService[T]) UpdateItemName(id, name string) error {
 	return s.WriteToFile(items)
 }
 
+// Updates the description of the item (Project or Task) by ID
+func (s *BaseService[T]) UpdateItemDescription(id, description string) error {
+	itemId, err := helpers.ValidateIdAndConvertToInt(id)
+	if err != nil {
+		return err
+	}
+
+	items := []T{}
+	if err := s.ReadFromFile(&items); err != nil {
+		return err
+	}
+
+	index, item, err := s.FindItemById(items, itemId)
+	if err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(item).Elem().FieldByName("Description")
+	v.SetString(description)
+
+	v = reflect.ValueOf(item).Elem().FieldByName("UpdatedAt")
+	v.Set(reflect.ValueOf(s.Clock.Now()))
+
+	items[index] = *item
+
+	return s.WriteToFile(items)
+}
+
 // Deletes the item (Project or Task) by ID
 func (s *BaseService[T]) DeleteItemById(id string) error {
 	itemId, err := helpers.ValidateIdAndConvertToInt(id)
@@ -157,14 +205,14 @@ func (s *BaseService[T]) UpdateItemStatus(id string, itemStatus status.ItemStatu
 	v.Set(reflect.ValueOf(itemStatus))
 
 	v = reflect.ValueOf(item).Elem().FieldByName("UpdatedAt")
-	v.Set(reflect.ValueOf(time.Now()))
+	v.Set(reflect.ValueOf(s.Clock.Now()))
 
 	items[index] = *item
 
 	return s.WriteToFile(items)
 }
 
-// Updates the total focus time of the item (Project or Task)
+// Updates the total focus time of the item (Project). Tasks are updated by the TaskService, which records their status change
 func (s *BaseService[T]) UpdateTotalSpentTime(id int, spentTime int) error {
 	items := []T{}
 	err := s.ReadFromFile(&items)
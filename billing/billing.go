@@ -0,0 +1,404 @@
+package billing
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/MuradIsayev/todo-tracker/clock"
+	"github.com/MuradIsayev/todo-tracker/constants"
+	"github.com/MuradIsayev/todo-tracker/project"
+	"github.com/MuradIsayev/todo-tracker/session"
+	"github.com/MuradIsayev/todo-tracker/task"
+)
+
+// ROUNDING MODES:
+const (
+	ROUND_NEAREST = "nearest"
+	ROUND_UP      = "up"
+	ROUND_DOWN    = "down"
+)
+
+// INVOICE FORMATS:
+const (
+	FORMAT_MARKDOWN = "md"
+	FORMAT_HTML     = "html"
+)
+
+// Rounding rounds the billed time to a multiple of IncrementMinutes (0 disables rounding)
+type Rounding struct {
+	IncrementMinutes int
+	Mode             string
+}
+
+func (r Rounding) Validate() error {
+	if r.IncrementMinutes < 0 {
+		return fmt.Errorf("rounding increment cannot be negative")
+	}
+
+	if r.Mode != ROUND_NEAREST && r.Mode != ROUND_UP && r.Mode != ROUND_DOWN {
+		return fmt.Errorf("invalid rounding mode %q, expected nearest, up or down", r.Mode)
+	}
+
+	return nil
+}
+
+// Rounds the seconds to the increment, e.g. 22 minutes are billed as 15 minutes when rounding to the nearest 15 minutes
+func (r Rounding) Round(seconds int) int {
+	if r.IncrementMinutes == 0 {
+		return seconds
+	}
+
+	increment := float64(r.IncrementMinutes * 60)
+	units := float64(seconds) / increment
+
+	switch r.Mode {
+	case ROUND_UP:
+		units = math.Ceil(units)
+	case ROUND_DOWN:
+		units = math.Floor(units)
+	default:
+		units = math.Round(units)
+	}
+
+	return int(units * increment)
+}
+
+// TimeEntry is the part of a recorded session that falls within the billed range
+type TimeEntry struct {
+	ProjectId      int
+	ProjectName    string
+	TaskId         int
+	TaskName       string
+	StartedAt      time.Time
+	EndedAt        time.Time
+	Seconds        int
+	RoundedSeconds int
+	HourlyRate     float64
+	Currency       string
+}
+
+// Returns the billed amount of the entry
+func (e *TimeEntry) Amount() float64 {
+	return amount(e.RoundedSeconds, e.HourlyRate)
+}
+
+// InvoiceLine is the focused time spent on one task of the invoiced project
+type InvoiceLine struct {
+	TaskId         int
+	TaskName       string
+	Seconds        int
+	RoundedSeconds int
+	Amount         float64
+}
+
+// Returns the billed hours of the line
+func (l InvoiceLine) Hours() float64 {
+	return float64(l.RoundedSeconds) / 3600
+}
+
+// Invoice is the billed focused time of a project within [From, To)
+type Invoice struct {
+	Number              string
+	IssuedAt            time.Time
+	From                time.Time
+	To                  time.Time
+	ProjectName         string
+	HourlyRate          float64
+	Currency            string
+	Rounding            Rounding
+	Lines               []InvoiceLine
+	TotalSeconds        int
+	TotalRoundedSeconds int
+	Total               float64
+}
+
+// Returns the billed hours of the invoice
+func (i *Invoice) TotalHours() float64 {
+	return float64(i.TotalRoundedSeconds) / 3600
+}
+
+type BillingService struct {
+	sessionService *session.SessionService
+	projectService *project.ProjectService
+	taskService    *task.TaskService
+	clock          clock.Clock
+}
+
+func NewBillingService(sessionService *session.SessionService, projectService *project.ProjectService, taskService *task.TaskService, clock clock.Clock) *BillingService {
+	return &BillingService{
+		sessionService: sessionService,
+		projectService: projectService,
+		taskService:    taskService,
+		clock:          clock,
+	}
+}
+
+func amount(seconds int, hourlyRate float64) float64 {
+	return math.Round(float64(seconds)/3600*hourlyRate*100) / 100
+}
+
+// Builds the time entries of the sessions within [from, to), each rounded on its own. A projectId of 0 includes all projects
+func (s *BillingService) BuildTimesheet(from, to time.Time, projectId int, rounding Rounding) ([]TimeEntry, error) {
+	if err := rounding.Validate(); err != nil {
+		return nil, err
+	}
+
+	if !to.After(from) {
+		return nil, fmt.Errorf("the end of the range must be after its start")
+	}
+
+	sessions, err := s.sessionService.FindSessions(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := s.projectService.FindProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	projectsById := map[int]project.Project{}
+	for _, project := range projects {
+		projectsById[project.Id] = project
+	}
+
+	tasks, err := s.taskService.FindTasksOfAllProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []TimeEntry{}
+	for _, session := range sessions {
+		if projectId != 0 && session.ProjectId != projectId {
+			continue
+		}
+
+		seconds := session.DurationWithin(from, to)
+		if seconds == 0 {
+			continue
+		}
+
+		entry := TimeEntry{
+			ProjectId:      session.ProjectId,
+			ProjectName:    fmt.Sprintf("Project ID=%d (deleted)", session.ProjectId),
+			TaskId:         session.TaskId,
+			TaskName:       fmt.Sprintf("Task ID=%d (deleted)", session.TaskId),
+			StartedAt:      session.StartedAt,
+			EndedAt:        session.EndedAt,
+			Seconds:        seconds,
+			RoundedSeconds: rounding.Round(seconds),
+		}
+
+		if project, ok := projectsById[session.ProjectId]; ok {
+			entry.ProjectName = project.Name
+			entry.HourlyRate = project.HourlyRate
+			entry.Currency = project.Currency
+		}
+
+		if task, ok := tasks[session.ProjectId][session.TaskId]; ok {
+			entry.TaskName = task.Name
+		}
+
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedAt.Before(entries[j].StartedAt)
+	})
+
+	return entries, nil
+}
+
+// Builds the invoice of the project within [from, to) with a line per task. The rounding is applied to the total of each task
+func (s *BillingService) BuildInvoice(projectId int, from, to time.Time, rounding Rounding) (*Invoice, error) {
+	if err := rounding.Validate(); err != nil {
+		return nil, err
+	}
+
+	projects, err := s.projectService.FindProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	var invoicedProject *project.Project
+	for i := range projects {
+		if projects[i].Id == projectId {
+			invoicedProject = &projects[i]
+		}
+	}
+
+	if invoicedProject == nil {
+		return nil, fmt.Errorf("project with ID=%d not found", projectId)
+	}
+
+	if invoicedProject.HourlyRate == 0 {
+		return nil, fmt.Errorf("project with ID=%d has no hourly rate, set it with `rate %d <hourly rate> [currency]`", projectId, projectId)
+	}
+
+	entries, err := s.BuildTimesheet(from, to, projectId, Rounding{Mode: rounding.Mode})
+	if err != nil {
+		return nil, err
+	}
+
+	invoice := &Invoice{
+		Number:      fmt.Sprintf("INV-%d-%s", projectId, from.Format("20060102")),
+		IssuedAt:    s.clock.Now(),
+		From:        from,
+		To:          to,
+		ProjectName: invoicedProject.Name,
+		HourlyRate:  invoicedProject.HourlyRate,
+		Currency:    invoicedProject.Currency,
+		Rounding:    rounding,
+	}
+
+	linesByTask := map[int]*InvoiceLine{}
+	for _, entry := range entries {
+		line, ok := linesByTask[entry.TaskId]
+		if !ok {
+			line = &InvoiceLine{TaskId: entry.TaskId, TaskName: entry.TaskName}
+			linesByTask[entry.TaskId] = line
+		}
+
+		line.Seconds += entry.Seconds
+	}
+
+	for _, line := range linesByTask {
+		line.RoundedSeconds = rounding.Round(line.Seconds)
+		line.Amount = amount(line.RoundedSeconds, invoice.HourlyRate)
+
+		invoice.Lines = append(invoice.Lines, *line)
+		invoice.TotalSeconds += line.Seconds
+		invoice.TotalRoundedSeconds += line.RoundedSeconds
+		invoice.Total += line.Amount
+	}
+
+	sort.Slice(invoice.Lines, func(i, j int) bool {
+		return invoice.Lines[i].TaskId < invoice.Lines[j].TaskId
+	})
+
+	return invoice, nil
+}
+
+// Formats the amount with its currency, e.g. 150.00 EUR
+func FormatMoney(amount float64, currency string) string {
+	if currency == "" {
+		return fmt.Sprintf("%.2f", amount)
+	}
+
+	return fmt.Sprintf("%.2f %s", amount, currency)
+}
+
+// Describes the rounding, e.g. "nearest 15 minutes"
+func DescribeRounding(rounding Rounding) string {
+	if rounding.IncrementMinutes == 0 {
+		return "none"
+	}
+
+	return fmt.Sprintf("%s %d minutes", rounding.Mode, rounding.IncrementMinutes)
+}
+
+// Writes the time entries as CSV with a header row
+func WriteTimesheetCSV(w io.Writer, entries []TimeEntry) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"date", "project", "task", "started_at", "ended_at", "minutes", "rounded_minutes", "hours", "rate", "amount", "currency"}); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		record := []string{
+			entry.StartedAt.Format(constants.DAY_FORMAT),
+			entry.ProjectName,
+			entry.TaskName,
+			entry.StartedAt.Format(time.RFC3339),
+			entry.EndedAt.Format(time.RFC3339),
+			strconv.Itoa(entry.Seconds / 60),
+			strconv.Itoa(entry.RoundedSeconds / 60),
+			strconv.FormatFloat(float64(entry.RoundedSeconds)/3600, 'f', 2, 64),
+			strconv.FormatFloat(entry.HourlyRate, 'f', 2, 64),
+			strconv.FormatFloat(entry.Amount(), 'f', 2, 64),
+			entry.Currency,
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// Writes the invoice as a Markdown document
+func WriteInvoiceMarkdown(w io.Writer, invoice *Invoice) error {
+	fmt.Fprintf(w, "# Invoice %s\n\n", invoice.Number)
+	fmt.Fprintf(w, "- **Project:** %s\n", invoice.ProjectName)
+	fmt.Fprintf(w, "- **Period:** %s - %s\n", invoice.From.Format(constants.DAY_FORMAT), invoice.To.AddDate(0, 0, -1).Format(constants.DAY_FORMAT))
+	fmt.Fprintf(w, "- **Issued:** %s\n", invoice.IssuedAt.Format(constants.DAY_FORMAT))
+	fmt.Fprintf(w, "- **Hourly rate:** %s\n", FormatMoney(invoice.HourlyRate, invoice.Currency))
+	fmt.Fprintf(w, "- **Rounding:** %s\n\n", DescribeRounding(invoice.Rounding))
+
+	fmt.Fprintln(w, "| Task | Hours | Rate | Amount |")
+	fmt.Fprintln(w, "|------|------:|-----:|-------:|")
+	for _, line := range invoice.Lines {
+		fmt.Fprintf(w, "| %s | %.2f | %s | %s |\n", line.TaskName, line.Hours(), FormatMoney(invoice.HourlyRate, invoice.Currency), FormatMoney(line.Amount, invoice.Currency))
+	}
+
+	_, err := fmt.Fprintf(w, "| **Total** | **%.2f** | | **%s** |\n", invoice.TotalHours(), FormatMoney(invoice.Total, invoice.Currency))
+
+	return err
+}
+
+var invoiceTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"money": FormatMoney,
+	"day":   func(t time.Time) string { return t.Format(constants.DAY_FORMAT) },
+	"lastDay": func(t time.Time) string {
+		return t.AddDate(0, 0, -1).Format(constants.DAY_FORMAT)
+	},
+	"rounding": DescribeRounding,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Number}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #ccc; padding: 0.4em; text-align: left; }
+td.number, th.number { text-align: right; }
+tfoot td { font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Invoice {{.Number}}</h1>
+<p>
+Project: {{.ProjectName}}<br>
+Period: {{day .From}} - {{lastDay .To}}<br>
+Issued: {{day .IssuedAt}}<br>
+Hourly rate: {{money .HourlyRate .Currency}}<br>
+Rounding: {{rounding .Rounding}}
+</p>
+<table>
+<thead><tr><th>Task</th><th class="number">Hours</th><th class="number">Rate</th><th class="number">Amount</th></tr></thead>
+<tbody>
+{{- range .Lines}}
+<tr><td>{{.TaskName}}</td><td class="number">{{printf "%.2f" .Hours}}</td><td class="number">{{money $.HourlyRate $.Currency}}</td><td class="number">{{money .Amount $.Currency}}</td></tr>
+{{- end}}
+</tbody>
+<tfoot><tr><td>Total</td><td class="number">{{printf "%.2f" .TotalHours}}</td><td></td><td class="number">{{money .Total .Currency}}</td></tr></tfoot>
+</table>
+</body>
+</html>
+`))
+
+// Writes the invoice as an HTML document
+func WriteInvoiceHTML(w io.Writer, invoice *Invoice) error {
+	return invoiceTemplate.Execute(w, invoice)
+}
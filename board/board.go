@@ -0,0 +1,231 @@
+package board
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MuradIsayev/todo-tracker/config"
+	"github.com/MuradIsayev/todo-tracker/constants"
+	"github.com/MuradIsayev/todo-tracker/query"
+	"github.com/MuradIsayev/todo-tracker/status"
+	"github.com/MuradIsayev/todo-tracker/task"
+)
+
+// Column is a column of the board, holding the tasks with its status that match its query
+type Column struct {
+	Title    string
+	Status   status.ItemStatus
+	Query    *query.Query // Narrows the tasks of the status, nil for all of them
+	WIPLimit int          // Maximum number of tasks, 0 for no limit
+	Tasks    []task.Task
+}
+
+// Checks if the task belongs to the column
+func (c Column) Match(t task.Task) bool {
+	return t.Status == c.Status && (c.Query == nil || c.Query.Match(t))
+}
+
+// Checks if the column holds more tasks than its WIP limit
+func (c Column) IsOverLimit() bool {
+	return c.WIPLimit > 0 && len(c.Tasks) > c.WIPLimit
+}
+
+// Returns the title of the column with its number of tasks, and its WIP limit if it has one
+func (c Column) Header() string {
+	if c.WIPLimit == 0 {
+		return fmt.Sprintf("%s (%d)", c.Title, len(c.Tasks))
+	}
+
+	header := fmt.Sprintf("%s (%d/%d)", c.Title, len(c.Tasks), c.WIPLimit)
+	if c.IsOverLimit() {
+		header += " !"
+	}
+
+	return header
+}
+
+// Board is a kanban board of the tasks of a project
+type Board struct {
+	Title   string
+	Columns []Column
+}
+
+// CardRecord is the machine-readable representation of a card of the board
+type CardRecord struct {
+	Column    string   `json:"column"`
+	WIPLimit  int      `json:"wipLimit"`
+	OverLimit bool     `json:"overLimit"`
+	Id        int      `json:"id"`
+	Name      string   `json:"name"`
+	Status    string   `json:"status"`
+	Tags      []string `json:"tags"`
+	DueDate   string   `json:"dueDate"`
+}
+
+// Columns of the board when none is configured
+var defaultColumns = []config.BoardColumnConfig{
+	{Status: "todo"},
+	{Status: "in-progress"},
+	{Status: "done"},
+}
+
+// Builds the board of the tasks with the configured columns, by default one column per status without WIP limits.
+// Every task is put in the first column it matches, and tasks matching no column are left out.
+// Relative dates of the column queries, e.g. due<7d, are resolved against now
+func BuildBoard(title string, tasks []task.Task, columnConfigs []config.BoardColumnConfig, now time.Time) (*Board, error) {
+	columns, err := ParseColumns(columnConfigs, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tasks {
+		for i := range columns {
+			if columns[i].Match(t) {
+				columns[i].Tasks = append(columns[i].Tasks, t)
+				break
+			}
+		}
+	}
+
+	return &Board{Title: title, Columns: columns}, nil
+}
+
+// Validates the configured columns of the board, parsing their queries at the time
+func ValidateColumns(columnConfigs []config.BoardColumnConfig, now time.Time) error {
+	_, err := ParseColumns(columnConfigs, now)
+	return err
+}
+
+// Returns the empty columns of the board from the config, by default one column per status
+func ParseColumns(columnConfigs []config.BoardColumnConfig, now time.Time) ([]Column, error) {
+	if len(columnConfigs) == 0 {
+		columnConfigs = defaultColumns
+	}
+
+	columns := []Column{}
+	// Statuses whose tasks are all taken by a column without a query
+	taken := map[status.ItemStatus]string{}
+	titles := map[string]bool{}
+
+	for _, columnConfig := range columnConfigs {
+		columnStatus, err := status.ParseStatus(columnConfig.Status)
+		if err != nil {
+			return nil, err
+		}
+
+		title := columnConfig.Title
+		if title == "" {
+			title = columnStatus.String()
+		}
+
+		if titles[title] {
+			return nil, fmt.Errorf("more than one column is titled %q, give the columns of the same status a title", title)
+		}
+		titles[title] = true
+
+		if previous, exists := taken[columnStatus]; exists {
+			return nil, fmt.Errorf("the %s column is always empty, the %s column before it takes all the %s tasks", title, previous, columnStatus)
+		}
+
+		if columnConfig.WIPLimit < 0 {
+			return nil, fmt.Errorf("WIP limit of the %s column cannot be negative", title)
+		}
+
+		column := Column{Title: title, Status: columnStatus, WIPLimit: columnConfig.WIPLimit}
+		if columnConfig.Query == "" {
+			taken[columnStatus] = title
+		} else {
+			column.Query, err = query.Parse(columnConfig.Query, now)
+			if err != nil {
+				return nil, fmt.Errorf("invalid query of the %s column: %v", title, err)
+			}
+		}
+
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+// Parses comma-separated WIP limits by status, e.g. "in-progress=3,todo=10"
+func ParseWIPLimits(value string) (map[status.ItemStatus]int, error) {
+	limits := map[status.ItemStatus]int{}
+	if strings.TrimSpace(value) == "" {
+		return limits, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		name, limitValue, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found {
+			return nil, fmt.Errorf("invalid WIP limit %q, expected status=limit", entry)
+		}
+
+		limitStatus, err := status.ParseStatus(name)
+		if err != nil {
+			return nil, err
+		}
+
+		limit, err := strconv.Atoi(limitValue)
+		if err != nil || limit < 0 {
+			return nil, fmt.Errorf("invalid WIP limit %q, expected a number not below 0", limitValue)
+		}
+
+		limits[limitStatus] = limit
+	}
+
+	return limits, nil
+}
+
+// Replaces the WIP limits of the columns with the statuses, every column of a status gets its limit
+func (b *Board) SetWIPLimits(limits map[status.ItemStatus]int) {
+	for i, column := range b.Columns {
+		if limit, exists := limits[column.Status]; exists {
+			b.Columns[i].WIPLimit = limit
+		}
+	}
+}
+
+// Returns a warning for every column holding more tasks than its WIP limit
+func (b *Board) Warnings() []string {
+	warnings := []string{}
+	for _, column := range b.Columns {
+		if column.IsOverLimit() {
+			warnings = append(warnings, fmt.Sprintf("%s has %d tasks, over its WIP limit of %d", column.Title, len(column.Tasks), column.WIPLimit))
+		}
+	}
+
+	return warnings
+}
+
+// Returns the cards of the board, column by column
+func (b *Board) Records() []CardRecord {
+	records := []CardRecord{}
+	for _, column := range b.Columns {
+		for _, t := range column.Tasks {
+			tags := t.Tags
+			if tags == nil {
+				tags = []string{}
+			}
+
+			var dueDate string
+			if t.DueDate != nil {
+				dueDate = t.DueDate.Format(constants.DAY_FORMAT)
+			}
+
+			records = append(records, CardRecord{
+				Column:    column.Title,
+				WIPLimit:  column.WIPLimit,
+				OverLimit: column.IsOverLimit(),
+				Id:        t.Id,
+				Name:      t.Name,
+				Status:    t.Status.String(),
+				Tags:      tags,
+				DueDate:   dueDate,
+			})
+		}
+	}
+
+	return records
+}
@@ -0,0 +1,62 @@
+package board
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MuradIsayev/todo-tracker/config"
+	"github.com/MuradIsayev/todo-tracker/status"
+	"github.com/MuradIsayev/todo-tracker/task"
+)
+
+func TestBuildBoardPutsTasksInTheFirstMatchingColumn(t *testing.T) {
+	tasks := []task.Task{
+		{Id: 1, Name: "Login", Status: status.IN_PROGRESS, Tags: []string{"review"}},
+		{Id: 2, Name: "Signup", Status: status.IN_PROGRESS},
+		{Id: 3, Name: "Logout", Status: status.IN_PROGRESS, Tags: []string{"review", "blocked"}},
+		{Id: 4, Name: "Docs", Status: status.TODO, Tags: []string{"review"}},
+	}
+	columnConfigs := []config.BoardColumnConfig{
+		{Status: "todo"},
+		{Status: "in-progress", Title: "Blocked", Query: "tag:blocked"},
+		{Status: "in-progress", Title: "Review", Query: "tag:review", WIPLimit: 1},
+		{Status: "in-progress"},
+	}
+
+	taskBoard, err := BuildBoard("Project 1: Demo", tasks, columnConfigs, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, column := range taskBoard.Columns {
+		ids := []string{}
+		for _, t := range column.Tasks {
+			ids = append(ids, t.Name)
+		}
+		got = append(got, column.Title+"="+strings.Join(ids, ","))
+	}
+
+	if want := "TODO=Docs Blocked=Logout Review=Login IN_PROGRESS=Signup"; strings.Join(got, " ") != want {
+		t.Errorf("columns = %q, want %q", strings.Join(got, " "), want)
+	}
+
+	if warnings := taskBoard.Warnings(); len(warnings) != 0 {
+		t.Errorf("warnings = %q, want none", warnings)
+	}
+}
+
+func TestValidateColumnsRejectsUnreachableColumns(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	for _, columnConfigs := range [][]config.BoardColumnConfig{
+		{{Status: "in-progress"}, {Status: "in-progress", Title: "Review", Query: "tag:review"}},
+		{{Status: "done"}, {Status: "done"}},
+		{{Status: "todo", Title: "Next", Query: "due<7d"}, {Status: "todo", Title: "Next"}},
+		{{Status: "todo", Query: "unknown:value"}},
+	} {
+		if err := ValidateColumns(columnConfigs, now); err == nil {
+			t.Errorf("columns %+v are valid, want an error", columnConfigs)
+		}
+	}
+}
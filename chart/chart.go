@@ -0,0 +1,317 @@
+package chart
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/MuradIsayev/todo-tracker/clock"
+	"github.com/MuradIsayev/todo-tracker/constants"
+	"github.com/MuradIsayev/todo-tracker/helpers"
+	"github.com/MuradIsayev/todo-tracker/project"
+	"github.com/MuradIsayev/todo-tracker/status"
+	"github.com/MuradIsayev/todo-tracker/task"
+)
+
+// Maximum number of columns of a burndown chart, longer ranges are sampled
+const maxBurndownColumns = 60
+
+// Height of a burndown chart in rows
+const burndownHeight = 12
+
+// Width of the longest bar of a velocity chart
+const velocityWidth = 40
+
+// Point is a single value of a chart
+type Point struct {
+	Label string    `json:"label"`
+	Time  time.Time `json:"time"`
+	Value int       `json:"value"`
+}
+
+// Chart is a titled series of points
+type Chart struct {
+	Title  string
+	Points []Point
+}
+
+type ChartService struct {
+	projectService *project.ProjectService
+	taskService    *task.TaskService
+	clock          clock.Clock
+}
+
+func NewChartService(projectService *project.ProjectService, taskService *task.TaskService, clock clock.Clock) *ChartService {
+	return &ChartService{
+		projectService: projectService,
+		taskService:    taskService,
+		clock:          clock,
+	}
+}
+
+// Builds the remaining (not done) tasks of the project at the end of each day, from the creation of its first task until today
+func (s *ChartService) BuildBurndown(projectId int) (*Chart, error) {
+	projectName := s.projectService.FindProjectNameById(fmt.Sprint(projectId))
+	if projectName == "" {
+		return nil, fmt.Errorf("project with ID=%d not found", projectId)
+	}
+
+	tasks, err := s.taskService.FindTasksOfProject(projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("project with ID=%d has no tasks", projectId)
+	}
+
+	start := tasks[0].CreatedAt
+	for _, task := range tasks {
+		if task.CreatedAt.Before(start) {
+			start = task.CreatedAt
+		}
+	}
+
+	firstDay := helpers.StartOfDay(start)
+	today := helpers.StartOfDay(s.clock.Now())
+	nbOfDays := int(today.Sub(firstDay).Hours()/24+0.5) + 1
+	step := (nbOfDays + maxBurndownColumns - 1) / maxBurndownColumns
+
+	chart := &Chart{Title: fmt.Sprintf("Burndown of %s", projectName)}
+	for day := 0; day < nbOfDays; day += step {
+		// The last column always shows today
+		if day+step >= nbOfDays {
+			day = nbOfDays - 1
+		}
+
+		dayStart := firstDay.AddDate(0, 0, day)
+		endOfDay := dayStart.AddDate(0, 0, 1).Add(-time.Nanosecond)
+
+		remaining := 0
+		for i := range tasks {
+			if taskStatus, exists := tasks[i].StatusAt(endOfDay); exists && taskStatus != status.DONE {
+				remaining++
+			}
+		}
+
+		chart.Points = append(chart.Points, Point{Label: dayStart.Format(constants.DAY_FORMAT), Time: dayStart, Value: remaining})
+	}
+
+	return chart, nil
+}
+
+// Builds the tasks completed per week over the last weeks (including the current one). A projectId of 0 includes all projects
+func (s *ChartService) BuildVelocity(nbOfWeeks int, projectId int) (*Chart, error) {
+	if nbOfWeeks <= 0 {
+		return nil, fmt.Errorf("number of weeks must be positive")
+	}
+
+	tasksByProject, err := s.taskService.FindTasksOfAllProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	title := "Velocity (tasks completed per week)"
+	if projectId != 0 {
+		if _, ok := tasksByProject[projectId]; !ok {
+			return nil, fmt.Errorf("project with ID=%d not found", projectId)
+		}
+
+		title = fmt.Sprintf("Velocity of %s (tasks completed per week)", s.projectService.FindProjectNameById(fmt.Sprint(projectId)))
+	}
+
+	firstWeek := helpers.StartOfWeek(s.clock.Now()).AddDate(0, 0, -7*(nbOfWeeks-1))
+
+	chart := &Chart{Title: title}
+	for week := 0; week < nbOfWeeks; week++ {
+		weekStart := firstWeek.AddDate(0, 0, 7*week)
+		chart.Points = append(chart.Points, Point{Label: weekStart.Format(constants.DAY_FORMAT), Time: weekStart})
+	}
+
+	for id, tasks := range tasksByProject {
+		if projectId != 0 && id != projectId {
+			continue
+		}
+
+		for _, task := range tasks {
+			completedAt, ok := task.CompletedAt()
+			if !ok || completedAt.Before(firstWeek) {
+				continue
+			}
+
+			week := int(helpers.StartOfWeek(completedAt).Sub(firstWeek).Hours()/24+0.5) / 7
+			if week < nbOfWeeks {
+				chart.Points[week].Value++
+			}
+		}
+	}
+
+	return chart, nil
+}
+
+func maxValue(points []Point) int {
+	maximum := 0
+	for _, point := range points {
+		maximum = max(maximum, point.Value)
+	}
+
+	return maximum
+}
+
+// Renders the chart as vertical columns, one per point, with Unicode blocks or plain ASCII characters
+func RenderColumns(w io.Writer, chart *Chart, unicode bool) {
+	fmt.Fprintln(w, chart.Title)
+
+	maximum := maxValue(chart.Points)
+	height := min(max(maximum, 1), burndownHeight)
+	labelWidth := len(fmt.Sprint(maximum))
+
+	// Eighths of a block let Unicode columns end between two rows
+	blocks := []string{" ", "▁", "▂", "▃", "▄", "▅", "▆", "▇", "█"}
+	fullBlock := "#"
+	if unicode {
+		fullBlock = blocks[8]
+	}
+
+	for row := height; row >= 1; row-- {
+		axisLabel := strings.Repeat(" ", labelWidth)
+		if row == height {
+			axisLabel = fmt.Sprintf("%*d", labelWidth, maximum)
+		}
+
+		var line strings.Builder
+		for _, point := range chart.Points {
+			scaled := 0.0
+			if maximum > 0 {
+				scaled = float64(point.Value) * float64(height) / float64(maximum)
+			}
+
+			switch {
+			case scaled >= float64(row):
+				line.WriteString(fullBlock)
+			case unicode && scaled > float64(row-1):
+				line.WriteString(blocks[int((scaled-float64(row-1))*8)])
+			default:
+				line.WriteString(" ")
+			}
+		}
+
+		fmt.Fprintf(w, "%s |%s\n", axisLabel, strings.TrimRight(line.String(), " "))
+	}
+
+	fmt.Fprintf(w, "%*d +%s\n", labelWidth, 0, strings.Repeat("-", len(chart.Points)))
+
+	if len(chart.Points) > 0 {
+		first, last := chart.Points[0].Label, chart.Points[len(chart.Points)-1].Label
+		padding := max(len(chart.Points)-len(first)-len(last), 1)
+		if len(chart.Points) == 1 {
+			fmt.Fprintf(w, "%s  %s\n", strings.Repeat(" ", labelWidth), first)
+		} else {
+			fmt.Fprintf(w, "%s  %s%s%s\n", strings.Repeat(" ", labelWidth), first, strings.Repeat(" ", padding), last)
+		}
+
+		fmt.Fprintf(w, "Now: %d\n", chart.Points[len(chart.Points)-1].Value)
+	}
+}
+
+// Renders the chart as horizontal bars, one row per point, with Unicode blocks or plain ASCII characters
+func RenderBars(w io.Writer, chart *Chart, unicode bool) {
+	fmt.Fprintln(w, chart.Title)
+
+	maximum := maxValue(chart.Points)
+	bar := "#"
+	if unicode {
+		bar = "█"
+	}
+
+	total := 0
+	for _, point := range chart.Points {
+		length := 0
+		if maximum > 0 {
+			length = point.Value * velocityWidth / maximum
+		}
+
+		fmt.Fprintf(w, "%s | %s\n", point.Label, strings.TrimLeft(fmt.Sprintf("%s %d", strings.Repeat(bar, length), point.Value), " "))
+		total += point.Value
+	}
+
+	if len(chart.Points) > 0 {
+		fmt.Fprintf(w, "Average: %.1f per week\n", float64(total)/float64(len(chart.Points)))
+	}
+}
+
+const (
+	svgWidth   = 640
+	svgHeight  = 320
+	svgPadding = 40
+)
+
+func writeSVGFrame(w io.Writer, chart *Chart, maximum int) {
+	fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" font-family=\"sans-serif\" font-size=\"12\">\n", svgWidth, svgHeight)
+	fmt.Fprintf(w, "<rect width=\"%d\" height=\"%d\" fill=\"white\"/>\n", svgWidth, svgHeight)
+	fmt.Fprintf(w, "<text x=\"%d\" y=\"20\" font-size=\"14\">%s</text>\n", svgPadding, html.EscapeString(chart.Title))
+	fmt.Fprintf(w, "<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"black\"/>\n", svgPadding, svgPadding, svgPadding, svgHeight-svgPadding)
+	fmt.Fprintf(w, "<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"black\"/>\n", svgPadding, svgHeight-svgPadding, svgWidth-svgPadding, svgHeight-svgPadding)
+	fmt.Fprintf(w, "<text x=\"%d\" y=\"%d\" text-anchor=\"end\">%d</text>\n", svgPadding-4, svgPadding+4, maximum)
+	fmt.Fprintf(w, "<text x=\"%d\" y=\"%d\" text-anchor=\"end\">0</text>\n", svgPadding-4, svgHeight-svgPadding+4)
+
+	if len(chart.Points) > 0 {
+		fmt.Fprintf(w, "<text x=\"%d\" y=\"%d\">%s</text>\n", svgPadding, svgHeight-svgPadding+16, chart.Points[0].Label)
+		fmt.Fprintf(w, "<text x=\"%d\" y=\"%d\" text-anchor=\"end\">%s</text>\n", svgWidth-svgPadding, svgHeight-svgPadding+16, chart.Points[len(chart.Points)-1].Label)
+	}
+}
+
+// Returns the vertical SVG coordinate of the value
+func svgY(value, maximum int) float64 {
+	plotHeight := float64(svgHeight - 2*svgPadding)
+	if maximum == 0 {
+		return float64(svgHeight - svgPadding)
+	}
+
+	return float64(svgHeight-svgPadding) - float64(value)*plotHeight/float64(maximum)
+}
+
+// Writes the chart as an SVG line chart
+func WriteLineSVG(w io.Writer, chart *Chart) error {
+	maximum := max(maxValue(chart.Points), 1)
+	writeSVGFrame(w, chart, maximum)
+
+	plotWidth := float64(svgWidth - 2*svgPadding)
+	coordinates := []string{}
+	for i, point := range chart.Points {
+		x := float64(svgPadding)
+		if len(chart.Points) > 1 {
+			x += float64(i) * plotWidth / float64(len(chart.Points)-1)
+		}
+
+		coordinates = append(coordinates, fmt.Sprintf("%.1f,%.1f", x, svgY(point.Value, maximum)))
+	}
+
+	fmt.Fprintf(w, "<polyline points=\"%s\" fill=\"none\" stroke=\"steelblue\" stroke-width=\"2\"/>\n", strings.Join(coordinates, " "))
+	_, err := fmt.Fprintln(w, "</svg>")
+
+	return err
+}
+
+// Writes the chart as an SVG bar chart
+func WriteBarSVG(w io.Writer, chart *Chart) error {
+	maximum := max(maxValue(chart.Points), 1)
+	writeSVGFrame(w, chart, maximum)
+
+	if len(chart.Points) > 0 {
+		slotWidth := float64(svgWidth-2*svgPadding) / float64(len(chart.Points))
+		for i, point := range chart.Points {
+			y := svgY(point.Value, maximum)
+			x := float64(svgPadding) + float64(i)*slotWidth + slotWidth*0.1
+
+			fmt.Fprintf(w, "<rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"steelblue\"><title>%s: %d</title></rect>\n",
+				x, y, slotWidth*0.8, float64(svgHeight-svgPadding)-y, point.Label, point.Value)
+		}
+	}
+
+	_, err := fmt.Fprintln(w, "</svg>")
+
+	return err
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Splits the command line into arguments at whitespace. Single or double quotes keep an argument together,
+// e.g. `delete --filter 'status:done older:30d'`, and a backslash escapes the next character outside single quotes
+func SplitArgs(line string) ([]string, error) {
+	args := []string{}
+	var arg strings.Builder
+	hasArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			hasArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			hasArg = true
+		case unicode.IsSpace(r):
+			if hasArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				hasArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			hasArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+
+	if hasArg {
+		args = append(args, arg.String())
+	}
+
+	return args, nil
+}
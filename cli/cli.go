@@ -0,0 +1,618 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Flag is an option of a command. The type of the default value (bool, int, float64 or string) is the type of the flag
+type Flag struct {
+	Name    string
+	Usage   string
+	Default any
+	// One letter name of the flag, e.g. "p" for -p
+	Short string
+	// Name of the value shown in the usage, e.g. "minutes"
+	Value string
+	// The command fails with a usage error if the flag is not given
+	Required bool
+	// Returns the values the flag is completed with in the shell
+	Complete func(ctx *Context) []Completion
+}
+
+// Command is the single definition of a command, from which its parsing, validation, usage and help are derived
+type Command struct {
+	Name    string
+	Aliases []string
+	// Synopsis of the positional arguments, e.g. "<task ID> <tag> [tag...]"
+	Args string
+	// One line shown in the list of commands
+	Summary string
+	// Further explanation shown in the help of the command
+	Description string
+	// Heading the command is listed under in the help
+	Group   string
+	Flags   []Flag
+	MinArgs int
+	// Maximum number of positional arguments, -1 for no limit
+	MaxArgs     int
+	Subcommands []*Command
+	// Returns the values the next positional argument is completed with in the shell
+	Complete func(ctx *Context) []Completion
+	// Hidden commands are not listed in the help
+	Hidden bool
+	// The arguments are passed to Run as they are, without parsing flags
+	RawArgs bool
+	// The positional arguments end with free text, e.g. a task name. Flags are only parsed before the first positional
+	// argument, so the words of the text starting with a dash are kept
+	FreeText bool
+	// Runs before the subcommand, with the flags of the subcommand and of this command parsed
+	Before func(ctx *Context) error
+	Run    func(ctx *Context) error
+}
+
+// Checks if the command is called by the name
+func (c *Command) hasName(name string) bool {
+	if c.Name == name {
+		return true
+	}
+
+	for _, alias := range c.Aliases {
+		if alias == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func findCommand(commands []*Command, name string) *Command {
+	for _, command := range commands {
+		if command.hasName(name) {
+			return command
+		}
+	}
+
+	return nil
+}
+
+func commandNames(commands []*Command) []string {
+	names := []string{}
+	for _, command := range commands {
+		if !command.Hidden {
+			names = append(names, command.Name)
+		}
+	}
+
+	return names
+}
+
+// Context is a parsed invocation of a command
+type Context struct {
+	Command *Command
+	// Names of the command and its parent commands, e.g. "timer start"
+	Path    string
+	Args    []string
+	parents []*Command
+	values  map[string]any
+	given   map[string]bool
+}
+
+func (c *Context) Bool(name string) bool {
+	value, _ := c.values[name].(*bool)
+	return value != nil && *value
+}
+
+func (c *Context) Int(name string) int {
+	if value, ok := c.values[name].(*int); ok {
+		return *value
+	}
+	return 0
+}
+
+func (c *Context) Float(name string) float64 {
+	if value, ok := c.values[name].(*float64); ok {
+		return *value
+	}
+	return 0
+}
+
+func (c *Context) String(name string) string {
+	if value, ok := c.values[name].(*string); ok {
+		return *value
+	}
+	return ""
+}
+
+// Checks if the flag was given on the command line
+func (c *Context) IsSet(name string) bool {
+	return c.given[name]
+}
+
+// Returns the value of the flag given on the command line as text, or an empty string if it was not given
+func (c *Context) Value(name string) string {
+	if !c.given[name] {
+		return ""
+	}
+
+	switch value := c.values[name].(type) {
+	case *bool:
+		return strconv.FormatBool(*value)
+	case *int:
+		return strconv.Itoa(*value)
+	case *float64:
+		return strconv.FormatFloat(*value, 'f', -1, 64)
+	case *string:
+		return *value
+	default:
+		return ""
+	}
+}
+
+// Returns a usage error of the command with the message
+func (c *Context) UsageError(format string, args ...any) error {
+	return &UsageError{Message: fmt.Sprintf(format, args...), Usage: Usage(c.Path, c.Command, c.parents)}
+}
+
+// UsageError is returned when a command is called with invalid arguments
+type UsageError struct {
+	Message string
+	// Usage line of the command, empty if the command is unknown
+	Usage string
+}
+
+func (e *UsageError) Error() string {
+	return e.Message
+}
+
+// Registry holds the commands of a mode, e.g. the normal mode or the REPL mode
+type Registry struct {
+	Commands []*Command
+	// Shown above the list of commands
+	Header string
+	// Shown below the list of commands
+	Footer string
+	// Global flags accepted by every command, before or after its name, e.g. --output
+	Flags []Flag
+	// Runs before every command, with its flags and the global flags parsed
+	Before func(ctx *Context) error
+}
+
+// Returns the command holding the global flags, which is the first parent of every command of the registry
+func (r *Registry) root() *Command {
+	return &Command{Flags: r.Flags, Before: r.Before}
+}
+
+// Returns the names of the commands of the registry
+func (r *Registry) Names() []string {
+	return commandNames(r.Commands)
+}
+
+// Finds the command and runs it with the arguments. Help is printed to w for --help or -h
+func (r *Registry) Run(w io.Writer, args []string) error {
+	root := r.root()
+	leadingFlags, args := splitLeadingFlags(root.Flags, args)
+	if len(args) == 0 || (len(leadingFlags) == 0 && isHelpFlag(args[0])) {
+		r.WriteHelp(w)
+		return nil
+	}
+
+	command := findCommand(r.Commands, args[0])
+	if command == nil {
+		return &UsageError{Message: fmt.Sprintf("unknown command %q, expected one of: %s", args[0], strings.Join(r.Names(), ", "))}
+	}
+
+	return runCommand(w, command, command.Name, []*Command{root}, append(append([]string{}, leadingFlags...), args[1:]...))
+}
+
+// Runs the command or descends into its subcommand. The flags of the parent commands are accepted by their subcommands
+func runCommand(w io.Writer, command *Command, path string, parents []*Command, args []string) error {
+	if len(command.Subcommands) > 0 {
+		// Flags of the command given before the subcommand are parsed with the flags of the subcommand
+		leadingFlags, args := splitLeadingFlags(allFlags(command, parents), args)
+		if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+			if len(args) > 0 && isHelpFlag(args[0]) {
+				WriteCommandHelp(w, path, command, parents)
+				return nil
+			}
+			return &UsageError{Message: fmt.Sprintf("missing %s command", path), Usage: Usage(path, command, parents)}
+		}
+
+		subcommand := findCommand(command.Subcommands, args[0])
+		if subcommand == nil {
+			return &UsageError{
+				Message: fmt.Sprintf("unknown %s command %q, expected one of: %s", path, args[0], strings.Join(commandNames(command.Subcommands), ", ")),
+				Usage:   Usage(path, command, parents),
+			}
+		}
+
+		return runCommand(w, subcommand, path+" "+subcommand.Name, append(parents, command), append(append([]string{}, leadingFlags...), args[1:]...))
+	}
+
+	if command.RawArgs {
+		return command.Run(&Context{Command: command, Path: path, Args: args, parents: parents, values: map[string]any{}, given: map[string]bool{}})
+	}
+
+	ctx, err := parse(command, path, parents, args)
+	if errors.Is(err, flag.ErrHelp) {
+		WriteCommandHelp(w, path, command, parents)
+		return nil
+	}
+	if err != nil {
+		return &UsageError{Message: err.Error(), Usage: Usage(path, command, parents)}
+	}
+
+	for _, parent := range parents {
+		if parent.Before != nil {
+			if err := parent.Before(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return command.Run(ctx)
+}
+
+// Splits the flags at the start of the arguments, e.g. "--project 3" in "task --project 3 add Docs", from the rest
+func splitLeadingFlags(flags []Flag, args []string) ([]string, []string) {
+	i := 0
+	for i < len(args) && strings.HasPrefix(args[i], "-") {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		f := findFlag(flags, name)
+		if f == nil {
+			break
+		}
+
+		i++
+		if _, isBool := f.Default.(bool); !isBool && !hasValue {
+			i++
+		}
+	}
+
+	i = min(i, len(args))
+
+	return args[:i], args[i:]
+}
+
+func findFlag(flags []Flag, name string) *Flag {
+	for i := range flags {
+		if flags[i].Name == name || (flags[i].Short != "" && flags[i].Short == name) {
+			return &flags[i]
+		}
+	}
+
+	return nil
+}
+
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "--help" || arg == "-help"
+}
+
+// Returns the flags of the command followed by the flags of its parents
+func allFlags(command *Command, parents []*Command) []Flag {
+	flags := append([]Flag{}, command.Flags...)
+	for i := len(parents) - 1; i >= 0; i-- {
+		flags = append(flags, parents[i].Flags...)
+	}
+
+	return flags
+}
+
+// Parses the flags and the arguments of the command and validates them
+func parse(command *Command, path string, parents []*Command, args []string) (*Context, error) {
+	ctx, err := parseFlags(command, path, parents, args)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range allFlags(command, parents) {
+		if f.Required && !ctx.given[f.Name] {
+			return nil, fmt.Errorf("missing --%s", f.Name)
+		}
+	}
+
+	if len(ctx.Args) < command.MinArgs {
+		return nil, fmt.Errorf("missing arguments")
+	}
+
+	if command.MaxArgs >= 0 && len(ctx.Args) > command.MaxArgs {
+		return nil, fmt.Errorf("too many arguments")
+	}
+
+	return ctx, nil
+}
+
+// Parses the flags, which may be given before, between or after the positional arguments (only before them for free text).
+// On error the context holds what was parsed up to the error
+func parseFlags(command *Command, path string, parents []*Command, args []string) (*Context, error) {
+	flagSet := flag.NewFlagSet(path, flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+
+	ctx := &Context{Command: command, Path: path, Args: []string{}, parents: parents, values: map[string]any{}, given: map[string]bool{}}
+	flags := allFlags(command, parents)
+	// Maps the names and short names of the flags to their names
+	flagNames := map[string]string{}
+	for _, f := range flags {
+		names := []string{f.Name}
+		if f.Short != "" {
+			names = append(names, f.Short)
+		}
+
+		for _, name := range names {
+			flagNames[name] = f.Name
+		}
+
+		switch defaultValue := f.Default.(type) {
+		case bool:
+			value := new(bool)
+			for _, name := range names {
+				flagSet.BoolVar(value, name, defaultValue, f.Usage)
+			}
+			ctx.values[f.Name] = value
+		case int:
+			value := new(int)
+			for _, name := range names {
+				flagSet.IntVar(value, name, defaultValue, f.Usage)
+			}
+			ctx.values[f.Name] = value
+		case float64:
+			value := new(float64)
+			for _, name := range names {
+				flagSet.Float64Var(value, name, defaultValue, f.Usage)
+			}
+			ctx.values[f.Name] = value
+		default:
+			stringValue, _ := defaultValue.(string)
+			value := new(string)
+			for _, name := range names {
+				flagSet.StringVar(value, name, stringValue, f.Usage)
+			}
+			ctx.values[f.Name] = value
+		}
+	}
+
+	// Marks the flags given on the command line
+	defer flagSet.Visit(func(f *flag.Flag) {
+		ctx.given[flagNames[f.Name]] = true
+	})
+
+	for {
+		if err := flagSet.Parse(args); err != nil {
+			return ctx, err
+		}
+
+		if flagSet.NArg() == 0 {
+			break
+		}
+
+		// Everything after "--" is a positional argument
+		if consumed := len(args) - flagSet.NArg(); consumed > 0 && args[consumed-1] == "--" {
+			ctx.Args = append(ctx.Args, flagSet.Args()...)
+			break
+		}
+
+		if command.FreeText {
+			ctx.Args = append(ctx.Args, flagSet.Args()...)
+			break
+		}
+
+		ctx.Args = append(ctx.Args, flagSet.Arg(0))
+		args = flagSet.Args()[1:]
+	}
+
+	return ctx, nil
+}
+
+// Returns the usage line of the command, e.g. "mark <task ID> [--done] [--in-progress] [--todo]".
+// The flags of a command taking free text are shown before its arguments, where they must be given
+func Usage(path string, command *Command, parents []*Command) string {
+	parts := []string{path}
+	if len(command.Subcommands) > 0 {
+		parts = append(parts, strings.Join(commandNames(command.Subcommands), "|"))
+	}
+
+	if command.Args != "" && !command.FreeText {
+		parts = append(parts, command.Args)
+	}
+
+	for _, f := range allFlags(command, parents) {
+		option := "--" + f.Name
+		if _, isBool := f.Default.(bool); !isBool {
+			option += " <" + valueName(f) + ">"
+		}
+
+		if !f.Required {
+			option = "[" + option + "]"
+		}
+		parts = append(parts, option)
+	}
+
+	if command.Args != "" && command.FreeText {
+		parts = append(parts, command.Args)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func valueName(f Flag) string {
+	if f.Value != "" {
+		return f.Value
+	}
+
+	switch f.Default.(type) {
+	case int, float64:
+		return "number"
+	default:
+		return "value"
+	}
+}
+
+// Writes the list of commands, grouped under their headings in the order the groups first appear
+func (r *Registry) WriteHelp(w io.Writer) {
+	if r.Header != "" {
+		fmt.Fprintln(w, r.Header)
+		fmt.Fprintln(w)
+	}
+
+	groups := []string{}
+	commandsByGroup := map[string][]*Command{}
+	for _, command := range r.Commands {
+		if command.Hidden {
+			continue
+		}
+
+		if _, exists := commandsByGroup[command.Group]; !exists {
+			groups = append(groups, command.Group)
+		}
+		commandsByGroup[command.Group] = append(commandsByGroup[command.Group], command)
+	}
+
+	for _, group := range groups {
+		if group != "" {
+			fmt.Fprintln(w, group)
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, command := range commandsByGroup[group] {
+			writeCommandLine(tw, command.Name, command)
+			for _, subcommand := range command.Subcommands {
+				writeCommandLine(tw, command.Name+" "+subcommand.Name, subcommand)
+			}
+		}
+		tw.Flush()
+		fmt.Fprintln(w)
+	}
+
+	if r.Footer != "" {
+		fmt.Fprintln(w, r.Footer)
+	}
+}
+
+func writeCommandLine(w io.Writer, path string, command *Command) {
+	synopsis := path
+	if command.Args != "" {
+		synopsis += " " + command.Args
+	}
+
+	fmt.Fprintf(w, "  %s\t%s\n", synopsis, command.Summary)
+}
+
+// Writes the help of the command: its usage, description, aliases, subcommands and flags
+func WriteCommandHelp(w io.Writer, path string, command *Command, parents []*Command) {
+	fmt.Fprintln(w, "USAGE:", Usage(path, command, parents))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, command.Summary)
+
+	if command.Description != "" {
+		fmt.Fprintln(w, command.Description)
+	}
+
+	if len(command.Aliases) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Aliases:", strings.Join(command.Aliases, ", "))
+	}
+
+	if len(command.Subcommands) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Commands:")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, subcommand := range command.Subcommands {
+			writeCommandLine(tw, path+" "+subcommand.Name, subcommand)
+		}
+		tw.Flush()
+	}
+
+	flags := allFlags(command, parents)
+	if len(flags) > 0 {
+		sort.SliceStable(flags, func(i, j int) bool { return flags[i].Required && !flags[j].Required })
+
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Flags:")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, f := range flags {
+			option := "--" + f.Name
+			if _, isBool := f.Default.(bool); !isBool {
+				option += " <" + valueName(f) + ">"
+			}
+
+			if f.Short != "" {
+				option = "-" + f.Short + ", " + option
+			}
+
+			fmt.Fprintf(tw, "  %s\t%s%s\n", option, f.Usage, describeDefault(f))
+		}
+		tw.Flush()
+	}
+}
+
+func describeDefault(f Flag) string {
+	switch defaultValue := f.Default.(type) {
+	case bool:
+		return ""
+	case string:
+		if defaultValue == "" {
+			return ""
+		}
+	case int:
+		if defaultValue == 0 {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf(" (default %v)", f.Default)
+}
+
+// Returns the command printing the help of the registry, or of the command given as arguments, e.g. `help timer start`
+func (r *Registry) HelpCommand(w io.Writer) *Command {
+	return &Command{
+		Name:    "help",
+		Args:    "[command] [subcommand]",
+		Summary: "Shows the commands, or the usage and flags of a command",
+		Group:   "General",
+		MaxArgs: 2,
+		Complete: func(ctx *Context) []Completion {
+			if len(ctx.Args) == 0 {
+				return commandCompletions(r.Commands)
+			}
+
+			if command := findCommand(r.Commands, ctx.Args[0]); command != nil {
+				return commandCompletions(command.Subcommands)
+			}
+
+			return nil
+		},
+		Run: func(ctx *Context) error {
+			if len(ctx.Args) == 0 {
+				r.WriteHelp(w)
+				return nil
+			}
+
+			command := findCommand(r.Commands, ctx.Args[0])
+			if command == nil {
+				return ctx.UsageError("unknown command %q", ctx.Args[0])
+			}
+
+			path := command.Name
+			parents := []*Command{r.root()}
+			if len(ctx.Args) == 2 {
+				subcommand := findCommand(command.Subcommands, ctx.Args[1])
+				if subcommand == nil {
+					return ctx.UsageError("unknown %s command %q", path, ctx.Args[1])
+				}
+
+				parents = append(parents, command)
+				path += " " + subcommand.Name
+				command = subcommand
+			}
+
+			WriteCommandHelp(w, path, command, parents)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// Returns a registry with a task command, whose add subcommand takes a free-text name, and a mark command
+func newTestRegistry(got *Context) *Registry {
+	capture := func(ctx *Context) error {
+		*got = *ctx
+		return nil
+	}
+
+	return &Registry{Commands: []*Command{
+		{
+			Name:  "task",
+			Flags: []Flag{{Name: "project", Short: "p", Default: "", Required: true}},
+			Subcommands: []*Command{
+				{Name: "add", Args: "<task name>", MinArgs: 1, MaxArgs: -1, FreeText: true, Run: capture},
+			},
+		},
+		{
+			Name: "mark", Args: "[task IDs]", MaxArgs: -1,
+			Flags: []Flag{{Name: "done", Default: false}},
+			Run:   capture,
+		},
+	}}
+}
+
+func TestFreeTextKeepsDashPrefixedWords(t *testing.T) {
+	for _, args := range [][]string{
+		{"task", "-p", "1", "add", "Handle", "--verbose", "flag"},
+		{"task", "add", "-p", "1", "Handle", "--verbose", "flag"},
+		{"task", "add", "--project=1", "--", "Handle", "--verbose", "flag"},
+	} {
+		var ctx Context
+		if err := newTestRegistry(&ctx).Run(io.Discard, args); err != nil {
+			t.Errorf("%q: %v", args, err)
+			continue
+		}
+
+		if name := strings.Join(ctx.Args, " "); name != "Handle --verbose flag" {
+			t.Errorf("%q: name = %q, want \"Handle --verbose flag\"", args, name)
+		}
+
+		if project := ctx.String("project"); project != "1" {
+			t.Errorf("%q: project = %q, want \"1\"", args, project)
+		}
+	}
+}
+
+func TestFlagsAfterArgumentsWithoutFreeText(t *testing.T) {
+	var ctx Context
+	if err := newTestRegistry(&ctx).Run(io.Discard, []string{"mark", "3", "--done", "5"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := strings.Join(ctx.Args, ","); ids != "3,5" || !ctx.Bool("done") {
+		t.Errorf("args = %q, done = %v, want 3,5 marked as done", ids, ctx.Bool("done"))
+	}
+}
+
+func TestFreeTextUsageShowsFlagsFirst(t *testing.T) {
+	registry := newTestRegistry(&Context{})
+	task := registry.Commands[0]
+
+	if usage := Usage("task add", task.Subcommands[0], []*Command{task}); usage != "task add --project <value> <task name>" {
+		t.Errorf("usage = %q", usage)
+	}
+}
+
+func TestGlobalFlagsBeforeAndAfterTheCommand(t *testing.T) {
+	var ctx Context
+	formats := []string{}
+	registry := newTestRegistry(&ctx)
+	registry.Flags = []Flag{{Name: "output", Short: "o", Default: "table"}}
+	registry.Before = func(ctx *Context) error {
+		formats = append(formats, ctx.String("output"))
+		return nil
+	}
+
+	for _, args := range [][]string{
+		{"-o", "json", "mark", "3"},
+		{"task", "--output=csv", "-p", "1", "add", "Docs"},
+		{"task", "-p", "1", "add", "Handle", "-o", "flag"},
+	} {
+		if err := registry.Run(io.Discard, args); err != nil {
+			t.Fatalf("%q: %v", args, err)
+		}
+	}
+
+	if got := strings.Join(formats, ","); got != "json,csv,table" {
+		t.Errorf("formats = %q, want json,csv,table", got)
+	}
+
+	if name := strings.Join(ctx.Args, " "); name != "Handle -o flag" {
+		t.Errorf("name = %q, want the flag kept in the free text", name)
+	}
+}
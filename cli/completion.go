@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Name of the hidden command the completion scripts call to complete the command line
+const COMPLETE_COMMAND = "__complete"
+
+// SHELLS with a completion script:
+const (
+	SHELL_BASH = "bash"
+	SHELL_ZSH  = "zsh"
+	SHELL_FISH = "fish"
+)
+
+// Completion is a value offered by the shell completion, with an optional description shown by zsh and fish
+type Completion struct {
+	Value       string
+	Description string
+}
+
+// Returns a completer offering the values
+func Values(values ...string) func(ctx *Context) []Completion {
+	return func(ctx *Context) []Completion {
+		completions := []Completion{}
+		for _, value := range values {
+			completions = append(completions, Completion{Value: value})
+		}
+
+		return completions
+	}
+}
+
+// Completes the last of the words, which are the command line after the program name
+func (r *Registry) Complete(words []string) []Completion {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+
+	current := words[len(words)-1]
+	preceding := words[:len(words)-1]
+
+	root := r.root()
+	leadingFlags, preceding := splitLeadingFlags(root.Flags, preceding)
+
+	var completions []Completion
+	if len(preceding) == 0 {
+		completions = r.completeBeforeCommand(root, leadingFlags, current)
+	} else if command := findCommand(r.Commands, preceding[0]); command != nil && !command.RawArgs {
+		commandWords := append(append([]string{}, leadingFlags...), preceding[1:]...)
+		completions = completeCommand(command, command.Name, []*Command{root}, commandWords, current)
+	}
+
+	matching := []Completion{}
+	for _, completion := range completions {
+		if strings.HasPrefix(completion.Value, current) {
+			matching = append(matching, completion)
+		}
+	}
+
+	return matching
+}
+
+// Completes the current word before the name of the command: a global flag, its value or a command
+func (r *Registry) completeBeforeCommand(root *Command, leadingFlags []string, current string) []Completion {
+	if pending := pendingFlag(root.Flags, leadingFlags); pending != nil {
+		if pending.Complete == nil {
+			return nil
+		}
+
+		ctx, _ := parseFlags(root, "", nil, leadingFlags[:len(leadingFlags)-1])
+		return pending.Complete(ctx)
+	}
+
+	if strings.HasPrefix(current, "-") {
+		return flagCompletions(root.Flags)
+	}
+
+	return commandCompletions(r.Commands)
+}
+
+// Completes the current word of the command, given the words between the command and the current word
+func completeCommand(command *Command, path string, parents []*Command, preceding []string, current string) []Completion {
+	if len(command.Subcommands) > 0 {
+		leadingFlags, rest := splitLeadingFlags(allFlags(command, parents), preceding)
+		if len(rest) > 0 {
+			subcommand := findCommand(command.Subcommands, rest[0])
+			if subcommand == nil {
+				return nil
+			}
+
+			subcommandWords := append(append([]string{}, leadingFlags...), rest[1:]...)
+			return completeCommand(subcommand, path+" "+subcommand.Name, append(parents, command), subcommandWords, current)
+		}
+	}
+
+	flags := allFlags(command, parents)
+	if pending := pendingFlag(flags, preceding); pending != nil {
+		if pending.Complete == nil {
+			return nil
+		}
+
+		ctx, _ := parseFlags(command, path, parents, preceding[:len(preceding)-1])
+		return pending.Complete(ctx)
+	}
+
+	if strings.HasPrefix(current, "-") {
+		// No flag is parsed within the free text of the command
+		if ctx, _ := parseFlags(command, path, parents, preceding); command.FreeText && len(ctx.Args) > 0 {
+			return nil
+		}
+
+		return flagCompletions(flags)
+	}
+
+	if len(command.Subcommands) > 0 {
+		return commandCompletions(command.Subcommands)
+	}
+
+	if command.Complete == nil {
+		return nil
+	}
+
+	ctx, _ := parseFlags(command, path, parents, preceding)
+	if command.MaxArgs >= 0 && len(ctx.Args) >= command.MaxArgs {
+		return nil
+	}
+
+	return command.Complete(ctx)
+}
+
+// Returns the flag waiting for its value at the end of the words, e.g. --project in "task add --project"
+func pendingFlag(flags []Flag, words []string) *Flag {
+	if len(words) == 0 {
+		return nil
+	}
+
+	last := words[len(words)-1]
+	if !strings.HasPrefix(last, "-") || strings.Contains(last, "=") {
+		return nil
+	}
+
+	f := findFlag(flags, strings.TrimLeft(last, "-"))
+	if f == nil {
+		return nil
+	}
+
+	if _, isBool := f.Default.(bool); isBool {
+		return nil
+	}
+
+	return f
+}
+
+func commandCompletions(commands []*Command) []Completion {
+	completions := []Completion{}
+	for _, command := range commands {
+		if !command.Hidden {
+			completions = append(completions, Completion{Value: command.Name, Description: command.Summary})
+		}
+	}
+
+	return completions
+}
+
+func flagCompletions(flags []Flag) []Completion {
+	completions := []Completion{}
+	for _, f := range flags {
+		completions = append(completions, Completion{Value: "--" + f.Name, Description: f.Usage})
+	}
+
+	return completions
+}
+
+// Writes the completions one per line, with the description separated by a tab
+func WriteCompletions(w io.Writer, completions []Completion) {
+	for _, completion := range completions {
+		if completion.Description == "" {
+			fmt.Fprintln(w, completion.Value)
+			continue
+		}
+
+		fmt.Fprintf(w, "%s\t%s\n", completion.Value, completion.Description)
+	}
+}
+
+var nonIdentifierRegex = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// Writes the completion script of the shell for the program. The script asks the program for the completions of the command line
+func WriteCompletionScript(w io.Writer, shell string, program string) error {
+	function := "_" + nonIdentifierRegex.ReplaceAllString(program, "_") + "_completion"
+
+	var script string
+	switch shell {
+	case SHELL_BASH:
+		script = bashCompletionScript
+	case SHELL_ZSH:
+		script = zshCompletionScript
+	case SHELL_FISH:
+		script = fishCompletionScript
+	default:
+		return fmt.Errorf("unsupported shell %q, expected bash, zsh or fish", shell)
+	}
+
+	script = strings.NewReplacer("PROGRAM", program, "FUNCTION", function, "COMPLETE", COMPLETE_COMMAND).Replace(script)
+	_, err := io.WriteString(w, script)
+
+	return err
+}
+
+const bashCompletionScript = `# bash completion for PROGRAM, load it with: source <(PROGRAM completion bash)
+FUNCTION() {
+    local IFS=$'\n' candidate
+    local candidates=($(PROGRAM COMPLETE "${COMP_WORDS[@]:1:COMP_CWORD-1}" "${COMP_WORDS[COMP_CWORD]}" 2>/dev/null))
+
+    COMPREPLY=()
+    for candidate in "${candidates[@]}"; do
+        COMPREPLY+=("$(printf '%q' "${candidate%%$'\t'*}")")
+    done
+}
+complete -F FUNCTION PROGRAM
+`
+
+const zshCompletionScript = `#compdef PROGRAM
+# zsh completion for PROGRAM, load it with: source <(PROGRAM completion zsh)
+FUNCTION() {
+    local candidate value
+    local -a completions
+    for candidate in "${(@f)$(PROGRAM COMPLETE "${(@)words[2,CURRENT-1]}" "${words[CURRENT]}" 2>/dev/null)}"; do
+        [[ -z "$candidate" ]] && continue
+        value="${candidate%%$'\t'*}"
+        if [[ "$candidate" == *$'\t'* ]]; then
+            completions+=("${value//:/\\:}:${candidate#*$'\t'}")
+        else
+            completions+=("${value//:/\\:}")
+        fi
+    done
+
+    _describe 'values' completions
+}
+compdef FUNCTION PROGRAM
+`
+
+const fishCompletionScript = `# fish completion for PROGRAM, load it with: PROGRAM completion fish | source
+complete -c PROGRAM -f -a '(PROGRAM COMPLETE (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)'
+`
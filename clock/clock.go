@@ -0,0 +1,168 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of the current time and of tickers for the services (timestamps, timers)
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks on its channel until it is stopped
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// RealClock uses the system time
+type RealClock struct{}
+
+func NewRealClock() *RealClock {
+	return &RealClock{}
+}
+
+func (c *RealClock) Now() time.Time {
+	return time.Now()
+}
+
+func (c *RealClock) NewTicker(d time.Duration) Ticker {
+	return &realTicker{ticker: time.NewTicker(d)}
+}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t *realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t *realTicker) Stop() {
+	t.ticker.Stop()
+}
+
+// FakeClock is a manually advanced clock, so whole timer sessions can be simulated instantly.
+// Ticks are delivered synchronously by Advance: it blocks until each tick is received or the ticker is stopped
+type FakeClock struct {
+	mu            sync.Mutex
+	tickerCreated *sync.Cond
+	now           time.Time
+	tickers       []*fakeTicker
+}
+
+func NewFakeClock(start time.Time) *FakeClock {
+	c := &FakeClock{now: start}
+	c.tickerCreated = sync.NewCond(&c.mu)
+
+	return c
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ticker := &fakeTicker{
+		clock:   c,
+		c:       make(chan time.Time),
+		stopped: make(chan struct{}),
+		period:  d,
+		next:    c.now.Add(d),
+	}
+	c.tickers = append(c.tickers, ticker)
+	c.tickerCreated.Broadcast()
+
+	return ticker
+}
+
+// Blocks until the clock has the given number of running tickers, e.g. until a timer started in a goroutine is ticking
+func (c *FakeClock) WaitForTickers(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.tickers) < n {
+		c.tickerCreated.Wait()
+	}
+}
+
+// Moves the clock forward, firing every tick that falls into the interval in chronological order
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+		var next *fakeTicker
+		for _, ticker := range c.tickers {
+			if !ticker.next.After(target) && (next == nil || ticker.next.Before(next.next)) {
+				next = ticker
+			}
+		}
+
+		if next == nil {
+			c.now = target
+			c.mu.Unlock()
+			return
+		}
+
+		c.now = next.next
+		next.next = next.next.Add(next.period)
+		now := c.now
+		c.mu.Unlock()
+
+		select {
+		case next.c <- now:
+		case <-next.stopped:
+		}
+	}
+}
+
+// Sets the clock to the given time without firing any ticks (e.g. to simulate a suspended system)
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = now
+	for _, ticker := range c.tickers {
+		ticker.next = now.Add(ticker.period)
+	}
+}
+
+type fakeTicker struct {
+	clock    *FakeClock
+	c        chan time.Time
+	stopped  chan struct{}
+	stopOnce sync.Once
+	period   time.Duration
+	next     time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.stopped)
+
+		t.clock.mu.Lock()
+		defer t.clock.mu.Unlock()
+
+		for i, ticker := range t.clock.tickers {
+			if ticker == t {
+				t.clock.tickers = append(t.clock.tickers[:i], t.clock.tickers[i+1:]...)
+				break
+			}
+		}
+	})
+}
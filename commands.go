@@ -0,0 +1,818 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/MuradIsayev/todo-tracker/billing"
+	"github.com/MuradIsayev/todo-tracker/chart"
+	"github.com/MuradIsayev/todo-tracker/cli"
+	"github.com/MuradIsayev/todo-tracker/clock"
+	"github.com/MuradIsayev/todo-tracker/config"
+	"github.com/MuradIsayev/todo-tracker/constants"
+	"github.com/MuradIsayev/todo-tracker/goal"
+	"github.com/MuradIsayev/todo-tracker/helpers"
+	"github.com/MuradIsayev/todo-tracker/output"
+	"github.com/MuradIsayev/todo-tracker/project"
+	"github.com/MuradIsayev/todo-tracker/render"
+	"github.com/MuradIsayev/todo-tracker/report"
+	"github.com/MuradIsayev/todo-tracker/search"
+	"github.com/MuradIsayev/todo-tracker/service"
+	"github.com/MuradIsayev/todo-tracker/stats"
+	"github.com/MuradIsayev/todo-tracker/status"
+	"github.com/MuradIsayev/todo-tracker/task"
+)
+
+// Returned by the exit command to leave the REPL mode
+var errExitREPL = errors.New("exit")
+
+// Services shared by the commands of the normal and the REPL mode
+type commandDependencies struct {
+	projectService            *project.ProjectService
+	taskService               *task.TaskService
+	circularDependencyManager *service.Manager
+	searchIndex               *search.Index
+	goalService               *goal.GoalService
+	reportService             *report.ReportService
+	billingService            *billing.BillingService
+	chartService              *chart.ChartService
+	statsService              *stats.StatsService
+	timers                    *timerDependencies
+	renderer                  *render.Renderer
+	clock                     clock.Clock
+
+	// Project the task commands act on, bound by the REPL mode or by the --project flag of the task command
+	projectId string
+}
+
+// Binds the task commands to the project
+func (deps *commandDependencies) bindProject(projectId string) {
+	deps.projectId = projectId
+	deps.taskService.AddProjectIdToTaskService(projectId)
+}
+
+// Sets the format of the renderer from the --output flag of the command. Without the flag, the format is left as it is
+func (deps *commandDependencies) applyOutputFormat(ctx *cli.Context) error {
+	if !ctx.IsSet("output") {
+		return nil
+	}
+
+	format, err := output.ParseFormat(ctx.String("output"))
+	if err != nil {
+		return ctx.UsageError("%v", err)
+	}
+
+	deps.renderer.SetFormat(format)
+	return nil
+}
+
+const timerModeDescription = "In the timer mode type (p)ause, (r)esume, (s)top to save the time or (e)xit without saving it, and (k)eep or (d)iscard\n" +
+	"the idle time after the timer was auto-paused. Set \"idleAfterMinutes\" in output/config.json to change the default of --idle."
+
+// Concatenates the lists of flags
+func joinFlags(flagLists ...[]cli.Flag) []cli.Flag {
+	flags := []cli.Flag{}
+	for _, flagList := range flagLists {
+		flags = append(flags, flagList...)
+	}
+
+	return flags
+}
+
+// Returns the flags selecting a status, e.g. --done
+func statusFlags(usage string) []cli.Flag {
+	return []cli.Flag{
+		{Name: "done", Usage: usage + " DONE", Default: false},
+		{Name: "in-progress", Usage: usage + " IN_PROGRESS", Default: false},
+		{Name: "todo", Usage: usage + " TODO", Default: false},
+	}
+}
+
+// Returns the status selected by the status flags, false if none is given
+func statusFromFlags(ctx *cli.Context) (status.ItemStatus, bool) {
+	switch {
+	case ctx.Bool("done"):
+		return status.DONE, true
+	case ctx.Bool("in-progress"):
+		return status.IN_PROGRESS, true
+	case ctx.Bool("todo"):
+		return status.TODO, true
+	default:
+		return -1, false
+	}
+}
+
+// Returns the flags overriding the layout of the task table
+func taskTableFlags(defaults config.TableConfig) []cli.Flag {
+	return []cli.Flag{
+		{Name: "columns", Usage: "Comma-separated columns to show: " + strings.Join(render.TaskColumnNames(), ", "), Default: strings.Join(defaults.Columns, ","), Value: "columns", Complete: cli.Values(render.TaskColumnNames()...)},
+		{Name: "sort", Usage: "Comma-separated columns to sort by, prefixed with - for descending order", Default: defaults.Sort, Value: "columns", Complete: cli.Values(render.TaskColumnNames()...)},
+		{Name: "overflow", Usage: "How long names are shown: wrap or truncate", Default: defaults.Overflow, Value: "wrap|truncate", Complete: cli.Values(render.OVERFLOW_WRAP, render.OVERFLOW_TRUNCATE)},
+		{Name: "max-name-width", Usage: "Maximum width of the name column (0 fits the terminal width)", Default: defaults.MaxNameWidth, Value: "width"},
+		{Name: "no-footer", Usage: "Hide the footer of the table", Default: defaults.HideFooter},
+	}
+}
+
+// Builds the layout of the task table from the flags
+func taskTableConfig(ctx *cli.Context) config.TableConfig {
+	tableConfig := config.TableConfig{
+		Sort:         ctx.String("sort"),
+		Overflow:     ctx.String("overflow"),
+		MaxNameWidth: ctx.Int("max-name-width"),
+		HideFooter:   ctx.Bool("no-footer"),
+	}
+
+	for _, column := range strings.Split(ctx.String("columns"), ",") {
+		if column = strings.ToLower(strings.TrimSpace(column)); column != "" {
+			tableConfig.Columns = append(tableConfig.Columns, column)
+		}
+	}
+
+	return tableConfig
+}
+
+// Returns the flags shared by the timer commands of the REPL mode
+func timerFlags(idleAfterMinutes int) []cli.Flag {
+	return []cli.Flag{
+		{Name: "silent", Usage: "Do not notify about timer events", Default: false},
+		{Name: "idle", Usage: "Auto-pause after the given minutes without activity (0 disables)", Default: idleAfterMinutes, Value: "minutes"},
+	}
+}
+
+const batchDescription = "Takes IDs and ranges such as `3,5,8-12`, or selects the tasks with a query of find, e.g. --filter 'status:done older:30d'.\n" +
+	"The tasks are changed at once: if any of them is not found, none is changed."
+
+// Flag of the commands running scripts
+var stopOnErrorFlag = cli.Flag{Name: "stop-on-error", Usage: "Stop the script at the first failing command", Default: false}
+
+// Global flag of the registries, accepted by every command
+var outputFlag = cli.Flag{
+	Name: "output", Short: "o", Usage: "Format of the list, show and report commands", Default: "", Value: "table|json|csv|tsv|plain|markdown",
+	Complete: cli.Values(string(output.TABLE), string(output.JSON), string(output.CSV), string(output.TSV), string(output.PLAIN), string(output.MARKDOWN)),
+}
+
+// Returns the --filter flag of the batch commands
+func filterFlag(action string) cli.Flag {
+	return cli.Flag{Name: "filter", Usage: action + " the tasks of the project matching the query instead of the given IDs", Default: "", Value: "query"}
+}
+
+// Returns the task commands, shared by the REPL mode and the task command of the normal mode
+func newTaskCommands(deps *commandDependencies) []*cli.Command {
+	const group = "Tasks"
+
+	return []*cli.Command{
+		{
+			Name: constants.ADD, Args: "<task name>", Summary: "Creates a task", Group: group, MinArgs: 1, MaxArgs: -1, FreeText: true,
+			Run: func(ctx *cli.Context) error {
+				return handleAddCommand(ctx, deps.taskService, deps.projectId, deps.renderer)
+			},
+		},
+		{
+			Name: constants.LIST, Summary: "Lists the tasks", Group: group,
+			Description: "Set the default layout in \"taskTable\" of output/config.json, e.g. {\"columns\": [\"id\", \"name\", \"due\"], \"sort\": \"due\"}.",
+			Flags:       joinFlags(statusFlags("List tasks with status"), taskTableFlags(deps.renderer.TaskTable())),
+			Run: func(ctx *cli.Context) error {
+				return handleListCommand(ctx, deps.taskService, deps.renderer)
+			},
+		},
+		{
+			Name: constants.UPDATE, Args: "<task ID> <new task name>", Summary: "Renames the task", Group: group, MinArgs: 2, MaxArgs: -1, FreeText: true,
+			Complete: firstArgument(completeTasks(deps)),
+			Run: func(ctx *cli.Context) error {
+				return handleUpdateCommand(ctx, deps.taskService, deps.renderer)
+			},
+		},
+		{
+			Name: constants.DELETE, Args: "[task IDs]", Summary: "Deletes the tasks, or all tasks with --all", Group: group, MaxArgs: -1,
+			Description: batchDescription,
+			Complete:    completeTasks(deps),
+			Flags: []cli.Flag{
+				{Name: "all", Usage: "Delete all tasks", Default: false},
+				filterFlag("Delete"),
+			},
+			Run: func(ctx *cli.Context) error {
+				return handleDeleteCommand(ctx, deps.taskService, deps.projectId, deps.renderer, deps.clock)
+			},
+		},
+		{
+			Name: constants.MARK, Args: "[task IDs]", Summary: "Sets the status of the tasks", Group: group, MaxArgs: -1,
+			Description: batchDescription,
+			Complete:    completeTasks(deps),
+			Flags:       joinFlags(statusFlags("Mark the tasks as"), []cli.Flag{filterFlag("Mark")}),
+			Run: func(ctx *cli.Context) error {
+				return handleMarkCommand(ctx, deps.taskService, deps.renderer, deps.clock)
+			},
+		},
+		{
+			Name: constants.TAG, Args: "<task ID> <tag> [tag...]", Summary: "Adds tags to the task, or removes them with --remove", Group: group, MinArgs: 2, MaxArgs: -1,
+			Complete:    firstArgument(completeTasks(deps)),
+			Description: "Tags group the focused time in reports and can be searched with find.",
+			Flags:       []cli.Flag{{Name: "remove", Usage: "Remove the tags from the task", Default: false}},
+			Run: func(ctx *cli.Context) error {
+				return handleTagCommand(ctx, deps.taskService, deps.renderer)
+			},
+		},
+		{
+			Name: constants.DUE, Args: "<task ID> [YYYY-MM-DD]", Summary: "Sets the due date of the task, or clears it with --clear", Group: group, MinArgs: 1, MaxArgs: 2,
+			Complete: firstArgument(completeTasks(deps)),
+			Flags:    []cli.Flag{{Name: "clear", Usage: "Clear the due date", Default: false}},
+			Run: func(ctx *cli.Context) error {
+				return handleDueCommand(ctx, deps.taskService, deps.renderer)
+			},
+		},
+		{
+			Name: constants.DESCRIBE, Args: "<task ID> <description>", Summary: "Sets the description of the task", Group: group, MinArgs: 2, MaxArgs: -1, FreeText: true,
+			Complete: firstArgument(completeTasks(deps)),
+			Run: func(ctx *cli.Context) error {
+				return handleDescribeCommand(ctx, deps.taskService, deps.renderer)
+			},
+		},
+		{
+			Name: constants.NOTE, Args: "<task ID> <text>", Summary: "Adds a note to the task", Group: group, MinArgs: 2, MaxArgs: -1, FreeText: true,
+			Complete: firstArgument(completeTasks(deps)),
+			Run: func(ctx *cli.Context) error {
+				return handleNoteCommand(ctx, deps.taskService, deps.renderer)
+			},
+		},
+		{
+			Name: constants.TIMER, Aliases: []string{constants.PERSISTENT_TIMER}, Args: "<task ID>", Summary: "Starts a countdown timer for the task and enters the timer mode", Group: group, MinArgs: 1, MaxArgs: 1,
+			Complete:    firstArgument(completeTasks(deps)),
+			Description: "Add --stopwatch to count the elapsed time upwards instead.\n" + timerModeDescription,
+			Flags: append([]cli.Flag{
+				{Name: "time", Usage: "Countdown duration in minutes", Default: 1, Value: "minutes"},
+				{Name: "stopwatch", Usage: "Count the elapsed time upwards instead of counting down", Default: false},
+			}, timerFlags(deps.timers.idleAfterMinutes)...),
+			Run: func(ctx *cli.Context) error {
+				return handleCountdownCommand(ctx, deps.taskService, deps.timers)
+			},
+		},
+		{
+			Name: constants.STOPWATCH, Args: "<task ID>", Summary: "Starts a stopwatch for the task and enters the timer mode", Group: group, MinArgs: 1, MaxArgs: 1,
+			Complete:    firstArgument(completeTasks(deps)),
+			Description: "Stopping the stopwatch saves the elapsed time.\n" + timerModeDescription,
+			Flags:       timerFlags(deps.timers.idleAfterMinutes),
+			Run: func(ctx *cli.Context) error {
+				return handleStopwatchCommand(ctx, deps.taskService, deps.timers)
+			},
+		},
+		{
+			Name: constants.POMODORO, Args: "<task ID>", Summary: "Alternates work and break intervals for the task", Group: group, MinArgs: 1, MaxArgs: 1,
+			Complete:    firstArgument(completeTasks(deps)),
+			Description: "Only work intervals are saved as spent time, a summary of the completed pomodoros is shown at the end.\n" + timerModeDescription,
+			Flags: append([]cli.Flag{
+				{Name: "work", Usage: "Work interval in minutes", Default: 25, Value: "minutes"},
+				{Name: "short-break", Usage: "Short break in minutes", Default: 5, Value: "minutes"},
+				{Name: "long-break", Usage: "Long break in minutes", Default: 15, Value: "minutes"},
+				{Name: "long-every", Usage: "Take a long break after every N pomodoros", Default: 4, Value: "N"},
+				{Name: "cycles", Usage: "Number of pomodoros in the session", Default: 4},
+			}, timerFlags(deps.timers.idleAfterMinutes)...),
+			Run: func(ctx *cli.Context) error {
+				return handlePomodoroCommand(ctx, deps.taskService, deps.timers)
+			},
+		},
+	}
+}
+
+// Returns the project commands, shared by the normal mode and the project command of the REPL mode
+func newProjectCommands(deps *commandDependencies) []*cli.Command {
+	const group = "Projects"
+
+	return []*cli.Command{
+		{
+			Name: constants.ADD, Args: "<project name>", Summary: "Creates a project", Group: group, MinArgs: 1, MaxArgs: -1, FreeText: true,
+			Run: func(ctx *cli.Context) error {
+				return handleProjectAddCommand(ctx, deps.projectService, deps.renderer)
+			},
+		},
+		{
+			Name: constants.LIST, Summary: "Lists the projects", Group: group,
+			Flags: statusFlags("List projects with status"),
+			Run: func(ctx *cli.Context) error {
+				return handleProjectListCommand(ctx, deps.projectService, deps.renderer)
+			},
+		},
+		{
+			Name: constants.UPDATE, Args: "<project ID> <new project name>", Summary: "Renames the project", Group: group, MinArgs: 2, MaxArgs: -1, FreeText: true,
+			Complete: firstArgument(completeProjects(deps.projectService, false)),
+			Run: func(ctx *cli.Context) error {
+				return handleProjectUpdateCommand(ctx, deps.projectService, deps.renderer)
+			},
+		},
+		{
+			Name: constants.DELETE, Args: "[project ID]", Summary: "Deletes the project and its tasks, or all projects with --all", Group: group, MaxArgs: 1,
+			Complete: firstArgument(completeProjects(deps.projectService, false)),
+			Flags:    []cli.Flag{{Name: "all", Usage: "Delete all projects", Default: false}},
+			Run: func(ctx *cli.Context) error {
+				return handleProjectDeleteCommand(ctx, deps.circularDependencyManager, deps.renderer)
+			},
+		},
+		{
+			Name: constants.MARK, Args: "<project ID>", Summary: "Sets the status of the project", Group: group, MinArgs: 1, MaxArgs: 1,
+			Complete: firstArgument(completeProjects(deps.projectService, false)),
+			Flags:    statusFlags("Mark the project as"),
+			Run: func(ctx *cli.Context) error {
+				return handleProjectMarkCommand(ctx, deps.projectService, deps.renderer)
+			},
+		},
+		{
+			Name: constants.DESCRIBE, Args: "<project ID> <description>", Summary: "Sets the description of the project", Group: group, MinArgs: 2, MaxArgs: -1, FreeText: true,
+			Complete: firstArgument(completeProjects(deps.projectService, false)),
+			Run: func(ctx *cli.Context) error {
+				return handleProjectDescribeCommand(ctx, deps.projectService, deps.renderer)
+			},
+		},
+	}
+}
+
+// Returns the commands of the REPL mode
+func newREPLRegistry(session *replSession) *cli.Registry {
+	deps := session.deps
+
+	registry := &cli.Registry{
+		Commands: newTaskCommands(deps),
+		Footer:   "Run `help <command>` or `<command> --help` for the usage and flags of a command.",
+		Flags:    []cli.Flag{outputFlag},
+		Before:   deps.applyOutputFormat,
+	}
+
+	const projectsGroup = "Projects"
+
+	registry.Commands = append(registry.Commands,
+		&cli.Command{
+			Name: constants.USE, Args: "<project ID | project name>", Summary: "Switches the REPL to the project", Group: projectsGroup, MinArgs: 1, MaxArgs: -1, FreeText: true,
+			Complete: firstArgument(completeProjects(deps.projectService, true)),
+			Run: func(ctx *cli.Context) error {
+				project, err := deps.projectService.FindProjectByIdOrName(strings.Join(ctx.Args, " "))
+				if err != nil {
+					return err
+				}
+
+				session.use(project)
+				deps.renderer.Messagef("Switched to the project \"%s\"", project.Name)
+
+				return nil
+			},
+		},
+		&cli.Command{
+			Name: constants.PROJECTS, Summary: "Lists the projects", Group: projectsGroup,
+			Flags: statusFlags("List projects with status"),
+			Run: func(ctx *cli.Context) error {
+				return handleProjectListCommand(ctx, deps.projectService, deps.renderer)
+			},
+		},
+		&cli.Command{
+			Name: constants.PROJECT, Summary: "Runs a project command of the normal mode", Group: projectsGroup,
+			Subcommands: newProjectCommands(deps),
+			Before: func(ctx *cli.Context) error {
+				if ctx.Command.Name != constants.DELETE {
+					return nil
+				}
+
+				if ctx.Bool("all") || (len(ctx.Args) > 0 && ctx.Args[0] == deps.projectId) {
+					return errors.New("cannot delete the active project, switch to another project with `use` first")
+				}
+
+				return nil
+			},
+		},
+		registry.HelpCommand(os.Stdout),
+		&cli.Command{
+			Name: "exit", Summary: "Exits the REPL mode", Group: "General",
+			Run: func(ctx *cli.Context) error {
+				return errExitREPL
+			},
+		},
+	)
+
+	return registry
+}
+
+// Returns the commands of the normal mode
+func newRegistry(deps *commandDependencies) *cli.Registry {
+	today := helpers.StartOfDay(deps.clock.Now())
+	lastWeek := today.AddDate(0, 0, -6).Format(constants.DAY_FORMAT)
+	lastMonth := today.AddDate(0, -1, 0).Format(constants.DAY_FORMAT)
+
+	dayRangeFlags := func(subject, from string) []cli.Flag {
+		return []cli.Flag{
+			{Name: "from", Usage: "First day of the " + subject, Default: from, Value: "YYYY-MM-DD"},
+			{Name: "to", Usage: "Last day of the " + subject + ", inclusive", Default: today.Format(constants.DAY_FORMAT), Value: "YYYY-MM-DD"},
+		}
+	}
+
+	roundingFlags := func(subject string, round int) []cli.Flag {
+		return []cli.Flag{
+			{Name: "round", Usage: "Round " + subject + " to the given minutes (0 disables rounding)", Default: round, Value: "minutes"},
+			{Name: "rounding", Usage: "Rounding mode: nearest, up or down", Default: billing.ROUND_NEAREST, Value: "nearest|up|down",
+				Complete: cli.Values(billing.ROUND_NEAREST, billing.ROUND_UP, billing.ROUND_DOWN)},
+		}
+	}
+
+	registry := &cli.Registry{
+		Header: "Todo Tracker CLI: manage projects and tasks, set timers, and keep track of your progress.",
+		Footer: "Run `help <command>` or `<command> --help` for the usage and flags of a command.\n\n" +
+			"Output formats: add --output table|json|csv|tsv|plain|markdown (or -o) to any command to change the format of the list,\n" +
+			"show and report commands. Machine-readable formats use raw seconds and RFC 3339 timestamps. Within free text, e.g. a task\n" +
+			"name, the flag must come before the text.\n\n" +
+			"Notifications: timer events ring the terminal bell by default. Set \"notifier\" in output/config.json to \"bell\",\n" +
+			"\"desktop\" (notify-send/D-Bus), \"command\" (runs \"notifyCommand\" with TODO_TRACKER_TITLE and TODO_TRACKER_MESSAGE) or \"silent\".",
+		Flags:  []cli.Flag{outputFlag},
+		Before: deps.applyOutputFormat,
+	}
+
+	const projectsGroup = "Projects"
+	const timersGroup = "Timers and goals"
+	const reportsGroup = "Reports"
+	const billingGroup = "Billing"
+
+	registry.Commands = append(newProjectCommands(deps), []*cli.Command{
+		{
+			Name: constants.REPL, Args: "<project ID | project name>", Summary: "Enters the REPL mode to manage the tasks of the project", Group: projectsGroup, MinArgs: 1, MaxArgs: 1,
+			Description: "Commands piped or redirected into the REPL mode, e.g. `repl 1 < commands.tt`, run as a script like with the run command.",
+			Complete:    firstArgument(completeProjects(deps.projectService, true)),
+			Flags:       []cli.Flag{stopOnErrorFlag},
+			Run: func(ctx *cli.Context) error {
+				return handleREPLCommand(ctx, deps)
+			},
+		},
+		{
+			Name: constants.TUI, Args: "[project ID | project name]", Summary: "Opens the full-screen terminal UI with a kanban board of the tasks", Group: projectsGroup, MaxArgs: 1,
+			Description: "Select a project on the left, then manage its tasks on the board. The keys of the focused pane are shown at the bottom.\n" +
+				"The columns and WIP limits of the board are configured in \"board\" of output/config.json, as for the board command.",
+			Complete: firstArgument(completeProjects(deps.projectService, true)),
+			Run: func(ctx *cli.Context) error {
+				return handleTUICommand(ctx, deps)
+			},
+		},
+		{
+			Name: constants.TASK, Summary: "Runs a task command of the REPL mode without entering it", Group: projectsGroup,
+			Description: "e.g. `task add --project Demo Write docs` or `task mark 3 --done -p 1`.\n" +
+				"Exits with status 1 if the command fails and 2 on invalid usage.",
+			Flags:       []cli.Flag{{Name: "project", Short: "p", Usage: "Project of the tasks", Default: "", Value: "project ID | project name", Required: true, Complete: completeProjects(deps.projectService, true)}},
+			Subcommands: newTaskCommands(deps),
+			Before: func(ctx *cli.Context) error {
+				project, err := deps.projectService.FindProjectByIdOrName(ctx.String("project"))
+				if err != nil {
+					return err
+				}
+
+				deps.bindProject(strconv.Itoa(project.Id))
+				return nil
+			},
+		},
+		{
+			Name: constants.PERSISTENT_TIMER, Summary: "Controls a timer that keeps running after the tool exits", Group: timersGroup,
+			Description: "A timer left running by a closed session is detected on startup and can be saved.",
+			Subcommands: []*cli.Command{
+				{
+					Name: constants.TIMER_START, Args: "<task ID>", Summary: "Starts the timer for the task", MinArgs: 1, MaxArgs: 1,
+					Complete: firstArgument(completeTasks(deps)),
+					Flags:    []cli.Flag{{Name: "project", Usage: "Project of the task", Default: 0, Value: "project ID", Required: true, Complete: completeProjects(deps.projectService, false)}},
+					Run: func(ctx *cli.Context) error {
+						return handleTimerStartCommand(ctx, deps.taskService, deps.timers.timerService, deps.renderer)
+					},
+				},
+				{
+					Name: constants.TIMER_PAUSE_COMMAND, Summary: "Pauses the running timer",
+					Run: func(ctx *cli.Context) error {
+						if _, err := deps.timers.timerService.Pause(); err != nil {
+							return err
+						}
+						deps.renderer.Message("Timer paused")
+						return nil
+					},
+				},
+				{
+					Name: constants.TIMER_RESUME_COMMAND, Summary: "Resumes the paused timer",
+					Run: func(ctx *cli.Context) error {
+						if _, err := deps.timers.timerService.Resume(); err != nil {
+							return err
+						}
+						deps.renderer.Message("Timer resumed")
+						return nil
+					},
+				},
+				{
+					Name: constants.TIMER_STOP_COMMAND, Summary: "Stops the running timer and saves the focused time",
+					Run: func(ctx *cli.Context) error {
+						return handleTimerStopCommand(deps.timers, deps.renderer)
+					},
+				},
+				{
+					Name: constants.TIMER_STATUS, Summary: "Shows the running timer and its focused time",
+					Run: func(ctx *cli.Context) error {
+						activeTimer, err := deps.timers.timerService.Load()
+						if err != nil {
+							return err
+						}
+						return deps.renderer.TimerStatus(activeTimer, deps.clock.Now())
+					},
+				},
+			},
+		},
+		{
+			Name: constants.GOAL, Summary: "Manages daily and weekly focus goals", Group: timersGroup,
+			Description: "Timers show the progress toward today's goal and notify once when a goal is reached.",
+			Subcommands: []*cli.Command{
+				{
+					Name: constants.GOAL_SET, Args: "<daily|weekly> <duration>", Summary: "Sets a focus goal, e.g. `goal set daily 4h`", MinArgs: 2, MaxArgs: 2,
+					Complete: firstArgument(cli.Values(goal.DAILY, goal.WEEKLY)),
+					Flags:    []cli.Flag{{Name: "project", Usage: "Count only the focused time of the project", Default: 0, Value: "project ID", Complete: completeProjects(deps.projectService, false)}},
+					Run: func(ctx *cli.Context) error {
+						return handleGoalSetCommand(ctx, deps.goalService, deps.renderer)
+					},
+				},
+				{
+					Name: constants.GOAL_LIST, Summary: "Lists the goals",
+					Run: func(ctx *cli.Context) error {
+						goals, err := deps.goalService.FindGoals()
+						if err != nil {
+							return err
+						}
+						return deps.renderer.Goals(goals)
+					},
+				},
+				{
+					Name: constants.GOAL_STATUS, Summary: "Compares the goals against the focused time of the current day and week",
+					Run: func(ctx *cli.Context) error {
+						progress, err := deps.goalService.FindGoalProgress()
+						if err != nil {
+							return err
+						}
+						return deps.renderer.GoalProgress(progress)
+					},
+				},
+				{
+					Name: constants.GOAL_DELETE, Args: "<goal ID>", Summary: "Deletes the goal", MinArgs: 1, MaxArgs: 1,
+					Complete: firstArgument(completeGoals(deps.goalService)),
+					Run: func(ctx *cli.Context) error {
+						if err := deps.goalService.DeleteGoal(ctx.Args[0]); err != nil {
+							return err
+						}
+						deps.renderer.Message("Goal deleted successfully")
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name: constants.REPORT, Summary: "Shows the focused time of the recorded sessions", Group: reportsGroup,
+			Description: "Shows the last 7 days grouped by day by default.",
+			Flags: append(dayRangeFlags("report", lastWeek),
+				cli.Flag{Name: "group-by", Usage: "Group the focused time by day, week, project, task or tag", Default: report.GROUP_BY_DAY, Value: "day|week|project|task|tag",
+					Complete: cli.Values(report.GROUP_BY_DAY, report.GROUP_BY_WEEK, report.GROUP_BY_PROJECT, report.GROUP_BY_TASK, report.GROUP_BY_TAG)},
+				cli.Flag{Name: "project", Usage: "Only include the focused time of the project", Default: 0, Value: "project ID", Complete: completeProjects(deps.projectService, false)},
+			),
+			Run: func(ctx *cli.Context) error {
+				return handleReportCommand(ctx, deps.reportService, deps.renderer)
+			},
+		},
+		{
+			Name: constants.STATS, Summary: "Summarises all projects", Group: reportsGroup,
+			Description: "Shows the created and completed tasks, streaks, most worked projects, average session length\n" +
+				"and a calendar heatmap of the focused time.",
+			Flags: []cli.Flag{
+				{Name: "weeks", Usage: "Number of weeks shown in the heatmap", Default: 26},
+				{Name: "ascii", Usage: "Draw the heatmap with ASCII characters instead of Unicode blocks", Default: false},
+			},
+			Run: func(ctx *cli.Context) error {
+				return handleStatsCommand(ctx, deps.statsService, deps.renderer, deps.clock)
+			},
+		},
+		{
+			Name: constants.BOARD, Args: "<project ID | project name>", Summary: "Shows the tasks of the project as a kanban board", Group: reportsGroup, MinArgs: 1, MaxArgs: 1,
+			Description: "Configure the columns in \"board\" of output/config.json, e.g. {\"columns\": [{\"status\": \"todo\", \"title\": \"Backlog\"},\n" +
+				"{\"status\": \"in-progress\", \"wipLimit\": 3}, {\"status\": \"done\"}], \"showDetails\": true}. A column over its WIP limit is flagged with a warning.\n" +
+				"A \"query\" of find splits a status into custom columns, e.g. {\"status\": \"in-progress\", \"title\": \"Review\", \"query\": \"tag:review\"}\n" +
+				"before the in-progress column. Every task is shown in the first column it matches.",
+			Complete: firstArgument(completeProjects(deps.projectService, true)),
+			Flags: []cli.Flag{
+				{Name: "details", Usage: "Show the tags and the due date on every card", Default: deps.renderer.BoardConfig().ShowDetails},
+				{Name: "wip", Usage: "Comma-separated WIP limits by status, e.g. in-progress=3,todo=10", Default: "", Value: "limits"},
+			},
+			Run: func(ctx *cli.Context) error {
+				return handleBoardCommand(ctx, deps)
+			},
+		},
+		{
+			Name: constants.BURNDOWN, Args: "<project ID>", Summary: "Plots the remaining open tasks of the project per day", Group: reportsGroup, MinArgs: 1, MaxArgs: 1,
+			Complete:    firstArgument(completeProjects(deps.projectService, false)),
+			Description: "The chart is built from the status history of the tasks.",
+			Flags: []cli.Flag{
+				{Name: "ascii", Usage: "Draw the chart with ASCII characters instead of Unicode blocks", Default: false},
+				{Name: "svg", Usage: "Also write the chart as an SVG file", Default: "", Value: "path"},
+			},
+			Run: func(ctx *cli.Context) error {
+				return handleBurndownCommand(ctx, deps.chartService, deps.renderer)
+			},
+		},
+		{
+			Name: constants.VELOCITY, Summary: "Plots the tasks completed per week", Group: reportsGroup,
+			Description: "The chart is built from the status history of the tasks.",
+			Flags: []cli.Flag{
+				{Name: "weeks", Usage: "Number of weeks to show, including the current one", Default: 8},
+				{Name: "project", Usage: "Only count the tasks of the project", Default: 0, Value: "project ID", Complete: completeProjects(deps.projectService, false)},
+				{Name: "ascii", Usage: "Draw the chart with ASCII characters instead of Unicode blocks", Default: false},
+				{Name: "svg", Usage: "Also write the chart as an SVG file", Default: "", Value: "path"},
+			},
+			Run: func(ctx *cli.Context) error {
+				return handleVelocityCommand(ctx, deps.chartService, deps.renderer)
+			},
+		},
+		{
+			Name: constants.FIND, Args: "<query>", Summary: "Finds the tasks of all projects matching the query", Group: reportsGroup, MinArgs: 1, MaxArgs: -1,
+			Description: "e.g. `find status:todo tag:backend due<7d spent>2h name~\"auth\" project:3`, all terms must match.\n" +
+				"Fields: name (: exact, ~ contains), status, tag, project, spent (< <= > >= :), due, created, updated (a day\n" +
+				"YYYY-MM-DD or a time relative to now such as 7d or -2w), older (not updated for a duration, e.g. older:30d).\n" +
+				"A term without a field searches the task names.",
+			Flags: taskTableFlags(deps.renderer.TaskTable()),
+			Run: func(ctx *cli.Context) error {
+				return handleFindCommand(ctx, deps.taskService, deps.renderer, deps.clock)
+			},
+		},
+		{
+			Name: constants.SEARCH, Args: "<words>", Summary: "Full-text search over all projects and tasks", Group: reportsGroup, MinArgs: 1, MaxArgs: -1,
+			Description: "Searches the names, descriptions, tags and notes, ranked by relevance. Words also match as a prefix or with a typo.\n" +
+				"The index in " + constants.SEARCH_INDEX_FILE_NAME + " is rebuilt when missing.",
+			Flags: []cli.Flag{{Name: "limit", Usage: "Maximum number of results (0 for all)", Default: 20}},
+			Run: func(ctx *cli.Context) error {
+				return handleSearchCommand(ctx, deps.taskService, deps.searchIndex, deps.renderer)
+			},
+		},
+		{
+			Name: constants.RATE, Args: "<project ID> <hourly rate> [currency]", Summary: "Sets the hourly rate of the project, e.g. `rate 1 80 EUR`", Group: billingGroup, MinArgs: 2, MaxArgs: 3,
+			Complete: firstArgument(completeProjects(deps.projectService, false)),
+			Run: func(ctx *cli.Context) error {
+				return handleRateCommand(ctx, deps.projectService, deps.renderer)
+			},
+		},
+		{
+			Name: constants.TIMESHEET, Summary: "Exports the time entries of the recorded sessions as CSV", Group: billingGroup,
+			Description: "Each time entry is rounded on its own.",
+			Flags: joinFlags(
+				dayRangeFlags("timesheet", lastWeek),
+				[]cli.Flag{{Name: "project", Usage: "Only include the time entries of the project", Default: 0, Value: "project ID", Complete: completeProjects(deps.projectService, false)}},
+				roundingFlags("each time entry", 0),
+				[]cli.Flag{{Name: "file", Usage: "Write the CSV to the file instead of the standard output", Default: "", Value: "path"}},
+			),
+			Run: func(ctx *cli.Context) error {
+				return handleTimesheetCommand(ctx, deps.billingService, deps.renderer)
+			},
+		},
+		{
+			Name: constants.INVOICE, Summary: "Generates an invoice of the project with a line item per task", Group: billingGroup,
+			Flags: joinFlags(
+				[]cli.Flag{{Name: "project", Usage: "Project to invoice", Default: 0, Value: "project ID", Required: true, Complete: completeProjects(deps.projectService, false)}},
+				dayRangeFlags("invoice", lastMonth),
+				roundingFlags("the time of each task", 15),
+				[]cli.Flag{
+					{Name: "format", Usage: "Invoice format: md or html", Default: billing.FORMAT_MARKDOWN, Value: "md|html", Complete: cli.Values(billing.FORMAT_MARKDOWN, billing.FORMAT_HTML)},
+					{Name: "file", Usage: "Write the invoice to the file instead of the standard output", Default: "", Value: "path"},
+				},
+			),
+			Run: func(ctx *cli.Context) error {
+				return handleInvoiceCommand(ctx, deps.billingService, deps.renderer)
+			},
+		},
+	}...)
+
+	registry.Commands = append(registry.Commands,
+		&cli.Command{
+			Name: constants.RUN, Args: "<script file | -> [NAME=value...]", Summary: "Runs the commands of a script file, or of the standard input for -", Group: "General", MinArgs: 1, MaxArgs: -1,
+			Description: "Every line is a command, e.g. `task -p \"$PROJECT\" add Write docs`. Lines starting with # are comments, `set NAME value`\n" +
+				"sets a variable and $NAME or ${NAME} is replaced by its value. Variables can also be given after the script file.\n" +
+				"The timer mode needs the standard input for its controls, so a script read from it cannot start a countdown,\n" +
+				"stopwatch or pomodoro, use the `timer` commands instead. Exits with status 1 if any command has failed.",
+			Flags: []cli.Flag{stopOnErrorFlag},
+			Run: func(ctx *cli.Context) error {
+				return handleRunCommand(ctx, registry, deps.renderer)
+			},
+		},
+		&cli.Command{
+			Name: constants.COMPLETION, Args: "<bash|zsh|fish>", Summary: "Prints the shell completion script", Group: "General", MinArgs: 1, MaxArgs: 1,
+			Description: "The script completes commands, flags, project IDs and names, and the task IDs of the --project.\n" +
+				"Load it with `source <(todo-tracker completion bash)` or `todo-tracker completion fish | source`.",
+			Complete: firstArgument(cli.Values(cli.SHELL_BASH, cli.SHELL_ZSH, cli.SHELL_FISH)),
+			Run: func(ctx *cli.Context) error {
+				if err := cli.WriteCompletionScript(os.Stdout, ctx.Args[0], filepath.Base(os.Args[0])); err != nil {
+					return ctx.UsageError("%s", err)
+				}
+				return nil
+			},
+		},
+		&cli.Command{
+			Name: cli.COMPLETE_COMMAND, Hidden: true, RawArgs: true,
+			Run: func(ctx *cli.Context) error {
+				cli.WriteCompletions(os.Stdout, registry.Complete(ctx.Args))
+				return nil
+			},
+		},
+		registry.HelpCommand(os.Stdout),
+	)
+
+	return registry
+}
+
+// Restricts the completer to the first positional argument
+func firstArgument(complete func(ctx *cli.Context) []cli.Completion) func(ctx *cli.Context) []cli.Completion {
+	return func(ctx *cli.Context) []cli.Completion {
+		if len(ctx.Args) > 0 {
+			return nil
+		}
+		return complete(ctx)
+	}
+}
+
+// Completes the project IDs, and the project names if withNames is set
+func completeProjects(projectService *project.ProjectService, withNames bool) func(ctx *cli.Context) []cli.Completion {
+	return func(ctx *cli.Context) []cli.Completion {
+		projects, err := projectService.FindProjects()
+		if err != nil {
+			return nil
+		}
+
+		completions := []cli.Completion{}
+		for _, project := range projects {
+			projectId := strconv.Itoa(project.Id)
+			completions = append(completions, cli.Completion{Value: projectId, Description: project.Name})
+
+			if withNames {
+				completions = append(completions, cli.Completion{Value: project.Name, Description: "project " + projectId})
+			}
+		}
+
+		return completions
+	}
+}
+
+// Completes the task IDs of the project given by --project, or of the project bound to the task commands
+func completeTasks(deps *commandDependencies) func(ctx *cli.Context) []cli.Completion {
+	return func(ctx *cli.Context) []cli.Completion {
+		projectId := deps.projectId
+		if projectValue := ctx.Value("project"); projectValue != "" {
+			project, err := deps.projectService.FindProjectByIdOrName(projectValue)
+			if err != nil {
+				return nil
+			}
+			projectId = strconv.Itoa(project.Id)
+		}
+
+		id, err := strconv.Atoi(projectId)
+		if err != nil {
+			return nil
+		}
+
+		tasks, err := deps.taskService.FindTasksOfProject(id)
+		if err != nil {
+			return nil
+		}
+
+		completions := []cli.Completion{}
+		for _, task := range tasks {
+			completions = append(completions, cli.Completion{Value: strconv.Itoa(task.Id), Description: task.Name})
+		}
+
+		return completions
+	}
+}
+
+// Completes the goal IDs
+func completeGoals(goalService *goal.GoalService) func(ctx *cli.Context) []cli.Completion {
+	return func(ctx *cli.Context) []cli.Completion {
+		goals, err := goalService.FindGoals()
+		if err != nil {
+			return nil
+		}
+
+		completions := []cli.Completion{}
+		for _, g := range goals {
+			completions = append(completions, cli.Completion{Value: strconv.Itoa(g.Id), Description: goal.DescribeGoal(g)})
+		}
+
+		return completions
+	}
+}
+
+// Prints the error of a command, followed by the usage of the command for usage errors
+func printCommandError(err error) {
+	fmt.Fprintln(os.Stderr, "Error:", err)
+
+	var usageError *cli.UsageError
+	if errors.As(err, &usageError) && usageError.Usage != "" {
+		fmt.Fprintln(os.Stderr, "USAGE:", usageError.Usage)
+	}
+}
+
+// Returns the exit status of a failed command: 2 on invalid usage and 1 otherwise
+func exitStatus(err error) int {
+	var usageError *cli.UsageError
+	if errors.As(err, &usageError) {
+		return 2
+	}
+
+	return 1
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Config holds the user preferences of the tracker
+type Config struct {
+	// Notifier used for timer events: "bell", "desktop", "command" or "silent"
+	Notifier string `json:"notifier"`
+	// Shell command run by the "command" notifier
+	NotifyCommand string `json:"notifyCommand"`
+	// Minutes without activity after which a running timer is auto-paused (0 disables idle detection)
+	IdleAfterMinutes int `json:"idleAfterMinutes"`
+	// Default layout of the task table
+	TaskTable TableConfig `json:"taskTable"`
+	// Columns of the kanban board
+	Board BoardConfig `json:"board"`
+}
+
+// TableConfig holds the layout of a table
+type TableConfig struct {
+	// Columns shown in the table, e.g. ["id", "name", "status", "due", "spent"]
+	Columns []string `json:"columns"`
+	// Comma-separated columns the rows are sorted by, prefixed with "-" for descending order, e.g. "-spent,name"
+	Sort string `json:"sort"`
+	// How names longer than the available width are shown: "wrap" or "truncate"
+	Overflow string `json:"overflow"`
+	// Maximum width of the name column (0 fits the table to the terminal width)
+	MaxNameWidth int `json:"maxNameWidth"`
+	// Hides the footer summarising the table
+	HideFooter bool `json:"hideFooter"`
+}
+
+// BoardConfig holds the layout of the kanban board
+type BoardConfig struct {
+	// Columns from left to right, by default one per status without WIP limits
+	Columns []BoardColumnConfig `json:"columns"`
+	// Shows the tags and the due date on every card
+	ShowDetails bool `json:"showDetails"`
+}
+
+// BoardColumnConfig holds a column of the kanban board
+type BoardColumnConfig struct {
+	// Status of the tasks in the column: "todo", "in-progress" or "done"
+	Status string `json:"status"`
+	// Query of find narrowing the tasks of the status, e.g. "tag:review", so a status can be split into several columns.
+	// A task is shown in the first column it matches
+	Query string `json:"query"`
+	// Title shown above the column, by default the status
+	Title string `json:"title"`
+	// Number of tasks above which the column warns (0 for no limit)
+	WIPLimit int `json:"wipLimit"`
+}
+
+// Loads the config from the file. A missing file results in the default config
+func LoadConfig(filePath string) (*Config, error) {
+	config := &Config{}
+
+	fileContent, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return nil, fmt.Errorf("cannot read file: %v", err)
+	}
+
+	if len(fileContent) > 0 {
+		if err := json.Unmarshal(fileContent, config); err != nil {
+			return nil, fmt.Errorf("cannot convert JSON to struct: %v", err)
+		}
+	}
+
+	return config, nil
+}
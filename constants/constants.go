@@ -2,14 +2,40 @@ package constants
 
 // MAIN COMMANDS:
 const (
-	ADD    string = "add"
-	UPDATE string = "update"
-	DELETE string = "delete"
-	LIST   string = "list"
-	MARK   string = "mark"
-	REPL   string = "repl"
-	TIMER  string = "t"
-	HELP   string = "help"
+	ADD       string = "add"
+	UPDATE    string = "update"
+	DELETE    string = "delete"
+	LIST      string = "list"
+	MARK      string = "mark"
+	REPL      string = "repl"
+	TIMER     string = "t"
+	STOPWATCH string = "start"
+	POMODORO  string = "pomodoro"
+	HELP      string = "help"
+
+	PERSISTENT_TIMER string = "timer"
+	GOAL             string = "goal"
+	REPORT           string = "report"
+	TAG              string = "tag"
+	RATE             string = "rate"
+	TIMESHEET        string = "timesheet"
+	INVOICE          string = "invoice"
+	BURNDOWN         string = "burndown"
+	VELOCITY         string = "velocity"
+	STATS            string = "stats"
+	DUE              string = "due"
+	FIND             string = "find"
+	SEARCH           string = "search"
+	DESCRIBE         string = "describe"
+	NOTE             string = "note"
+	TASK             string = "task"
+	COMPLETION       string = "completion"
+	USE              string = "use"
+	PROJECTS         string = "projects"
+	PROJECT          string = "project"
+	TUI              string = "tui"
+	BOARD            string = "board"
+	RUN              string = "run"
 )
 
 // TABLE COLUMNS:
@@ -21,15 +47,40 @@ const (
 	COLUMN_UPDATE_DATE      = "Update Date"
 	COLUMN_TOTAL_SPENT_TIME = "Total Spent Time"
 	COLUMN_TOTAL_TASKS      = "Total Tasks"
+	COLUMN_PERIOD           = "Period"
+	COLUMN_TARGET           = "Target"
+	COLUMN_PROJECT          = "Project"
+	COLUMN_FOCUSED          = "Focused"
+	COLUMN_PROGRESS         = "Progress"
+	COLUMN_DAY              = "Day"
+	COLUMN_WEEK             = "Week"
+	COLUMN_TASK             = "Task"
+	COLUMN_TAG              = "Tag"
+	COLUMN_SESSIONS         = "Sessions"
+	COLUMN_SHARE            = "Share"
+	COLUMN_CREATED_TASKS    = "Created Tasks"
+	COLUMN_COMPLETED_TASKS  = "Completed Tasks"
+	COLUMN_OPEN_TASKS       = "Open Tasks"
+	COLUMN_TAGS             = "Tags"
+	COLUMN_DUE_DATE         = "Due Date"
+	COLUMN_TYPE             = "Type"
+	COLUMN_SCORE            = "Score"
 	COLUMN_EMPTY            = ""
 )
 
 // REFERENCE DATE FORMAT:
 const DATE_FORMAT = "2006-01-02 15:04:05"
+const DAY_FORMAT = "2006-01-02"
 
 // FILE NAMES:
 const TASK_FILE_NAME = "task.json"
 const PROJECT_FILE_NAME = "output/projects.json"
+const TIMER_FILE_NAME = "output/timer.json"
+const CONFIG_FILE_NAME = "output/config.json"
+const SESSION_FILE_NAME = "output/sessions.json"
+const GOAL_FILE_NAME = "output/goals.json"
+const SEARCH_INDEX_FILE_NAME = "output/search-index.json"
+const HISTORY_FILE_NAME = "history.txt"
 
 // TIMER COMMANDS of REPL mode:
 const (
@@ -37,4 +88,24 @@ const (
 	TIMER_RESUME string = "r"
 	TIMER_STOP   string = "s"
 	TIMER_EXIT   string = "e"
+
+	TIMER_KEEP_IDLE    string = "k"
+	TIMER_DISCARD_IDLE string = "d"
+)
+
+// PERSISTENT TIMER COMMANDS of normal mode:
+const (
+	TIMER_START          string = "start"
+	TIMER_PAUSE_COMMAND  string = "pause"
+	TIMER_RESUME_COMMAND string = "resume"
+	TIMER_STOP_COMMAND   string = "stop"
+	TIMER_STATUS         string = "status"
+)
+
+// GOAL COMMANDS of normal mode:
+const (
+	GOAL_SET    string = "set"
+	GOAL_LIST   string = "list"
+	GOAL_STATUS string = "status"
+	GOAL_DELETE string = "delete"
 )
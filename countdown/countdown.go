@@ -2,11 +2,19 @@ package countdown
 
 import (
 	"fmt"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/MuradIsayev/todo-tracker/clock"
+	"github.com/MuradIsayev/todo-tracker/constants"
+	"github.com/MuradIsayev/todo-tracker/goal"
 	"github.com/MuradIsayev/todo-tracker/helpers"
+	"github.com/MuradIsayev/todo-tracker/notifier"
 	"github.com/MuradIsayev/todo-tracker/service"
+	"github.com/MuradIsayev/todo-tracker/session"
 	"github.com/MuradIsayev/todo-tracker/task"
+	"github.com/MuradIsayev/todo-tracker/timer"
 )
 
 type CountdownService struct {
@@ -16,11 +24,42 @@ type CountdownService struct {
 	DoneChan                  chan bool
 	DisplayChan               chan string
 	ExitChan                  chan bool
+	ActivityChan              chan bool
+	IdleDecisionChan          chan bool     // true keeps the idle time, false discards it
+	IdleAfter                 time.Duration // Auto-pause after this period without activity (0 disables idle detection)
+	idlePending               atomic.Bool
 	taskService               *task.TaskService
 	circularDependencyManager *service.Manager
+	timerService              timer.TimerManager
+	notifier                  notifier.Notifier
+	goalService               goal.GoalManager
+	clock                     clock.Clock
+	pid                       int // Identifies the session owning the persisted timer
 }
 
-func NewCountdownService(taskService *task.TaskService, circularDependencyManager *service.Manager) *CountdownService {
+// Describes the work and break intervals of a pomodoro session (in minutes)
+type PomodoroConfig struct {
+	WorkMinutes       int
+	ShortBreakMinutes int
+	LongBreakMinutes  int
+	LongBreakEvery    int
+	Cycles            int
+}
+
+// A longer gap between two ticks means the system was suspended
+const suspendThreshold = 5 * time.Second
+
+// Describes how an interval of the countdown has ended
+type intervalResult int
+
+const (
+	intervalCompleted intervalResult = iota
+	intervalStopped
+	intervalExited
+)
+
+// The pid identifies the session in the persisted timer, so the timer of another session is never removed
+func NewCountdownService(taskService *task.TaskService, circularDependencyManager *service.Manager, timerService timer.TimerManager, notifier notifier.Notifier, goalService goal.GoalManager, clock clock.Clock, pid int) *CountdownService {
 	return &CountdownService{
 		PauseChan:                 make(chan bool),
 		ResumeChan:                make(chan bool),
@@ -28,50 +67,396 @@ func NewCountdownService(taskService *task.TaskService, circularDependencyManage
 		DoneChan:                  make(chan bool),
 		DisplayChan:               make(chan string),
 		ExitChan:                  make(chan bool),
+		ActivityChan:              make(chan bool),
+		IdleDecisionChan:          make(chan bool),
 		taskService:               taskService,
 		circularDependencyManager: circularDependencyManager,
+		timerService:              timerService,
+		notifier:                  notifier,
+		goalService:               goalService,
+		clock:                     clock,
+		pid:                       pid,
 	}
 }
 
-// Starts the countdown for the task and sends the remaining time to the display channel
-func (cs *CountdownService) StartCountdown(task *task.Task, countdownMinutes int) {
-	remainingSeconds := countdownMinutes * 60
+// Runs the timer until the limit is reached (a non-positive limit counts up until the timer is stopped),
+// handling pause/resume/stop/exit signals. Returns the elapsed (not paused) seconds and how the timer has ended.
+// The timer of the task is persisted until the caller saves or ignores the elapsed time,
+// so the session can be recovered if the process dies (breaks are not persisted, their task is nil).
+// Returns an error if the timer cannot be persisted, e.g. because another session has started a timer
+func (cs *CountdownService) runTimer(task *task.Task, limitSeconds int, formatDisplay func(elapsedSeconds int) string) (int, intervalResult, error) {
+	elapsedSeconds := 0
 
-	ticker := time.NewTicker(1 * time.Second)
+	if task != nil {
+		if _, err := cs.timerService.Start(task, cs.pid, max(limitSeconds, 0)); err != nil {
+			return 0, intervalExited, fmt.Errorf("cannot start the timer: %v", err)
+		}
+	}
+
+	ticker := cs.clock.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 	paused := false
 
-	for remainingSeconds > 0 {
+	// Idle and suspend detection are only done for the focused time of a task (not for breaks).
+	// A suspended system is always detected, even if the idle detection is disabled
+	detectIdle := task != nil && cs.IdleAfter > 0
+	detectSuspend := task != nil
+	lastActivity := cs.clock.Now()
+	lastTick := cs.clock.Now().Round(0) // Strip the monotonic clock reading to detect wall-clock jumps
+	idleSeconds := 0                    // Idle interval waiting for the keep/discard decision of the user
+
+	// Goals that the focused time of the task counts towards
+	goalProgress := []goal.GoalProgress{}
+	if task != nil {
+		goalProgress, _ = cs.goalService.FindProjectGoalProgress(task.ProjectId)
+	}
+
+	display := func() {
+		cs.DisplayChan <- formatDisplay(elapsedSeconds) + formatDailyGoalProgress(goalProgress, elapsedSeconds)
+	}
+
+	// The timer keeps running in memory if the persisted timer cannot be updated, the user is warned about it
+	updatePersistedTimer := func(update func() (*timer.ActiveTimer, error)) {
+		if _, err := update(); err != nil {
+			cs.DisplayChan <- fmt.Sprintf("Warning: cannot update the persisted timer: %v", err)
+		}
+	}
+
+	pause := func() {
+		paused = true
+		if task != nil {
+			updatePersistedTimer(cs.timerService.Pause)
+		}
+	}
+
+	resume := func() {
+		paused = false
+		lastActivity = cs.clock.Now()
+		if task != nil {
+			updatePersistedTimer(cs.timerService.Resume)
+		}
+	}
+
+	// Auto-pauses the timer and asks the user what to do with the idle interval
+	markIdle := func(seconds int, reason string) {
+		idleSeconds = seconds
+		cs.idlePending.Store(true)
+		pause()
+		cs.DisplayChan <- fmt.Sprintf("%s, timer paused. Type (k)eep or (d)iscard the idle %s.", reason, strings.TrimSpace(helpers.FormatSpendTime(seconds)))
+	}
+
+	for limitSeconds <= 0 || elapsedSeconds < limitSeconds || idleSeconds > 0 {
 		select {
 		case <-cs.StopChan:
-			cs.DisplayChan <- fmt.Sprintf("Countdown stopped early for the task --> \"%s\".", task.Name)
-			helpers.BeepBeep()
-			cs.circularDependencyManager.UpdateTaskAndProjectTimers(task.Id, task.ProjectId, countdownMinutes*60-remainingSeconds) // Save the elapsed time
-			// cs.taskService.UpdateTaskSpentTime(task.Id, countdownMinutes*60-remainingSeconds)                                      // Save the elapsed time
-			close(cs.DoneChan) // Signal that the countdown has ended
-			return
+			return elapsedSeconds, intervalStopped, nil
 		case <-cs.ExitChan:
-			cs.DisplayChan <- fmt.Sprintf("Countdown session for task --> \"%s\" ignored.", task.Name)
-			close(cs.DoneChan) // Exit without saving any time
-			return
+			return elapsedSeconds, intervalExited, nil
+		case <-cs.ActivityChan:
+			lastActivity = cs.clock.Now()
+		case keepIdle := <-cs.IdleDecisionChan:
+			if idleSeconds == 0 {
+				continue
+			}
+
+			if keepIdle {
+				cs.DisplayChan <- "Idle time kept. Timer resumed."
+			} else {
+				elapsedSeconds -= idleSeconds
+				if task != nil {
+					discardedSeconds := idleSeconds
+					updatePersistedTimer(func() (*timer.ActiveTimer, error) {
+						return cs.timerService.Adjust(-discardedSeconds)
+					})
+				}
+				cs.DisplayChan <- "Idle time discarded. Timer resumed."
+			}
+
+			idleSeconds = 0
+			cs.idlePending.Store(false)
+			resume()
 		case <-cs.PauseChan:
-			paused = true
-			cs.DisplayChan <- "Countdown paused. Type (r)esume to continue."
+			pause()
+			cs.DisplayChan <- "Timer paused. Type (r)esume to continue."
 		case <-cs.ResumeChan:
+			if idleSeconds > 0 {
+				cs.DisplayChan <- "Type (k)eep or (d)iscard the idle time to resume the timer."
+			} else if paused {
+				resume()
+				cs.DisplayChan <- "Timer resumed."
+			}
+		case tick := <-ticker.C():
+			now := tick.Round(0) // The time of the tick, as the clock may have moved on since
+			gap := now.Sub(lastTick)
+			lastTick = now
+
 			if paused {
-				paused = false
-				cs.DisplayChan <- "Countdown resumed."
+				continue
+			}
+
+			if detectSuspend && gap >= suspendThreshold {
+				// The system was suspended, the skipped time is counted until the user decides about it
+				skippedSeconds := int((gap - time.Second).Seconds())
+				if limitSeconds > 0 {
+					skippedSeconds = min(skippedSeconds, limitSeconds-elapsedSeconds-1)
+				}
+				elapsedSeconds += skippedSeconds + 1
+				display()
+				if skippedSeconds > 0 {
+					markIdle(skippedSeconds, "System was suspended")
+				}
+				continue
 			}
-		case <-ticker.C:
-			if !paused {
-				remainingSeconds--
-				cs.DisplayChan <- fmt.Sprintf("Task --> \"%s\": %d:%02d", task.Name, remainingSeconds/60, remainingSeconds%60)
+
+			elapsedSeconds++
+			display()
+			cs.remindReachedGoals(goalProgress, elapsedSeconds)
+
+			if detectIdle && now.Sub(lastActivity) >= cs.IdleAfter {
+				markIdle(min(int(now.Sub(lastActivity).Seconds()), elapsedSeconds), "No activity detected")
 			}
 		}
 	}
 
-	cs.DisplayChan <- fmt.Sprintf("Countdown complete for the task --> \"%s\". Now press (e) to exit", task.Name)
-	helpers.BeepBeep()
-	cs.circularDependencyManager.UpdateTaskAndProjectTimers(task.Id, task.ProjectId, countdownMinutes*60-remainingSeconds) // Save the elapsed time
-	close(cs.DoneChan)                                                                                                     // Signal that the countdown has ended
+	return elapsedSeconds, intervalCompleted, nil
+}
+
+// Fires a one-shot reminder for each goal reached by the focused time
+func (cs *CountdownService) remindReachedGoals(goalProgress []goal.GoalProgress, elapsedSeconds int) {
+	for _, reachedGoal := range cs.goalService.CollectReachedGoals(goalProgress, elapsedSeconds) {
+		cs.notifier.Notify("Goal reached", fmt.Sprintf("You have reached your goal of %s", goal.DescribeGoal(reachedGoal)))
+	}
+}
+
+// Formats the progress towards today's goal of the project (or of all projects) for the display line
+func formatDailyGoalProgress(goalProgress []goal.GoalProgress, elapsedSeconds int) string {
+	var dailyProgress *goal.GoalProgress
+	for i, p := range goalProgress {
+		if p.Goal.Period == goal.DAILY && (dailyProgress == nil || p.Goal.ProjectId != 0) {
+			dailyProgress = &goalProgress[i]
+		}
+	}
+
+	if dailyProgress == nil {
+		return ""
+	}
+
+	return fmt.Sprintf(" | Today's goal: %s/%s (%d%%)",
+		helpers.FormatHoursMinutes(dailyProgress.FocusedSeconds+elapsedSeconds),
+		helpers.FormatHoursMinutes(dailyProgress.Goal.TargetSeconds),
+		dailyProgress.Percent(elapsedSeconds),
+	)
+}
+
+// Checks if the timer is auto-paused and waits for the user to keep or discard the idle time
+func (cs *CountdownService) IsIdlePending() bool {
+	return cs.idlePending.Load()
+}
+
+// Saves the elapsed time to the task and its project, and removes the persisted timer.
+// The sessions are recorded over the focused segments of the persisted timer, unless another session has replaced it
+func (cs *CountdownService) saveElapsedTime(task *task.Task, elapsedSeconds int) error {
+	activeTimer, err := cs.timerService.Load()
+	if err != nil {
+		return err
+	}
+
+	var segments []session.Segment
+	if activeTimer != nil && activeTimer.IsOwnedBy(cs.pid, task.Id) {
+		segments = activeTimer.FocusedSegments(cs.clock.Now())
+	}
+
+	if err := cs.circularDependencyManager.UpdateTaskAndProjectTimers(task.Id, task.ProjectId, elapsedSeconds, segments); err != nil {
+		return err
+	}
+
+	return cs.discardTimer(task)
+}
+
+// Removes the persisted timer of the task, unless it belongs to another session (e.g. started from another shell)
+func (cs *CountdownService) discardTimer(task *task.Task) error {
+	return cs.timerService.DiscardOwned(cs.pid, task.Id)
+}
+
+// Message shown when the persisted timer cannot be started, the error is returned to the caller
+const timerNotStartedMessage = "Timer not started. Now press (e) to exit"
+
+// Counts down the given number of seconds and sends the remaining time to the display channel
+func (cs *CountdownService) runInterval(task *task.Task, seconds int, formatDisplay func(remainingSeconds int) string) (int, intervalResult, error) {
+	return cs.runTimer(task, seconds, func(elapsedSeconds int) string {
+		return formatDisplay(seconds - elapsedSeconds)
+	})
+}
+
+// Starts the countdown for the task and sends the remaining time to the display channel
+func (cs *CountdownService) StartCountdown(task *task.Task, countdownMinutes int) error {
+	defer close(cs.DoneChan) // Signal that the countdown has ended
+
+	elapsedSeconds, result, err := cs.runInterval(task, countdownMinutes*60, func(remainingSeconds int) string {
+		return fmt.Sprintf("Task --> \"%s\": %d:%02d", task.Name, remainingSeconds/60, remainingSeconds%60)
+	})
+	if err != nil {
+		cs.DisplayChan <- timerNotStartedMessage
+		return err
+	}
+
+	switch result {
+	case intervalStopped:
+		cs.DisplayChan <- fmt.Sprintf("Countdown stopped early for the task --> \"%s\".", task.Name)
+		cs.notifier.Notify("Countdown stopped", fmt.Sprintf("Countdown stopped early for the task \"%s\"", task.Name))
+	case intervalExited:
+		cs.DisplayChan <- fmt.Sprintf("Countdown session for task --> \"%s\" ignored.", task.Name)
+		return cs.discardTimer(task) // Exit without saving any time
+	default:
+		cs.DisplayChan <- fmt.Sprintf("Countdown complete for the task --> \"%s\". Now press (e) to exit", task.Name)
+		cs.notifier.Notify("Countdown complete", fmt.Sprintf("Countdown complete for the task \"%s\"", task.Name))
+	}
+
+	return cs.saveElapsedTime(task, elapsedSeconds)
+}
+
+// Counts the elapsed time of the task upwards until the stopwatch is stopped or exited
+func (cs *CountdownService) StartStopwatch(task *task.Task) error {
+	defer close(cs.DoneChan) // Signal that the stopwatch has ended
+
+	elapsedSeconds, result, err := cs.runTimer(task, 0, func(elapsedSeconds int) string {
+		return fmt.Sprintf("Task --> \"%s\": %d:%02d:%02d", task.Name, elapsedSeconds/3600, (elapsedSeconds%3600)/60, elapsedSeconds%60)
+	})
+	if err != nil {
+		cs.DisplayChan <- timerNotStartedMessage
+		return err
+	}
+
+	if result == intervalExited {
+		cs.DisplayChan <- fmt.Sprintf("Stopwatch session for task --> \"%s\" ignored.", task.Name)
+		return cs.discardTimer(task) // Exit without saving any time
+	}
+
+	cs.DisplayChan <- fmt.Sprintf("Stopwatch stopped for the task --> \"%s\" after %s.", task.Name, strings.TrimSpace(helpers.FormatSpendTime(elapsedSeconds)))
+	cs.notifier.Notify("Stopwatch stopped", fmt.Sprintf("Stopwatch stopped for the task \"%s\"", task.Name))
+
+	return cs.saveElapsedTime(task, elapsedSeconds)
+}
+
+// Alternates work and break intervals for the task. Only work intervals are recorded as spent time
+func (cs *CountdownService) StartPomodoro(task *task.Task, config PomodoroConfig) error {
+	completedPomodoros := 0
+	focusedSeconds := 0
+
+	defer close(cs.DoneChan) // Signal that the pomodoro session has ended
+
+	for cycle := 1; cycle <= config.Cycles; cycle++ {
+		elapsedSeconds, result, err := cs.runInterval(task, config.WorkMinutes*60, func(remainingSeconds int) string {
+			return fmt.Sprintf("Pomodoro %d/%d | Work --> \"%s\": %d:%02d", cycle, config.Cycles, task.Name, remainingSeconds/60, remainingSeconds%60)
+		})
+		if err != nil {
+			cs.DisplayChan <- fmt.Sprintf("Pomodoro %d for task --> \"%s\" not started. %s Now press (e) to exit", cycle, task.Name, pomodoroSummary(completedPomodoros, focusedSeconds))
+			return err
+		}
+
+		if result == intervalExited {
+			cs.DisplayChan <- fmt.Sprintf("Pomodoro %d for task --> \"%s\" ignored. %s", cycle, task.Name, pomodoroSummary(completedPomodoros, focusedSeconds))
+			return cs.discardTimer(task)
+		}
+
+		// Save the focused time of the work interval
+		if err := cs.saveElapsedTime(task, elapsedSeconds); err != nil {
+			return err
+		}
+		focusedSeconds += elapsedSeconds
+
+		if result == intervalStopped {
+			cs.DisplayChan <- fmt.Sprintf("Pomodoro session stopped early for the task --> \"%s\". %s", task.Name, pomodoroSummary(completedPomodoros, focusedSeconds))
+			cs.notifier.Notify("Pomodoro stopped", fmt.Sprintf("Pomodoro session stopped early for the task \"%s\"", task.Name))
+			return nil
+		}
+
+		completedPomodoros++
+
+		if cycle == config.Cycles {
+			break
+		}
+
+		breakName, breakMinutes := "Short break", config.ShortBreakMinutes
+		if config.LongBreakEvery > 0 && completedPomodoros%config.LongBreakEvery == 0 {
+			breakName, breakMinutes = "Long break", config.LongBreakMinutes
+		}
+
+		cs.notifier.Notify(fmt.Sprintf("Pomodoro %d complete", cycle), fmt.Sprintf("%s of %d minutes for the task \"%s\"", breakName, breakMinutes, task.Name))
+
+		// Breaks are not persisted, so their timer cannot fail to start
+		_, result, _ = cs.runInterval(nil, breakMinutes*60, func(remainingSeconds int) string {
+			return fmt.Sprintf("Pomodoro %d/%d | %s: %d:%02d", cycle, config.Cycles, breakName, remainingSeconds/60, remainingSeconds%60)
+		})
+
+		if result != intervalCompleted {
+			cs.DisplayChan <- fmt.Sprintf("Pomodoro session ended during the break for the task --> \"%s\". %s", task.Name, pomodoroSummary(completedPomodoros, focusedSeconds))
+			return nil
+		}
+
+		cs.notifier.Notify(fmt.Sprintf("%s is over", breakName), fmt.Sprintf("Back to work on the task \"%s\"", task.Name))
+	}
+
+	cs.DisplayChan <- fmt.Sprintf("Pomodoro session complete for the task --> \"%s\". %s Now press (e) to exit", task.Name, pomodoroSummary(completedPomodoros, focusedSeconds))
+	cs.notifier.Notify("Pomodoro session complete", pomodoroSummary(completedPomodoros, focusedSeconds))
+
+	return nil
+}
+
+func pomodoroSummary(completedPomodoros, focusedSeconds int) string {
+	if completedPomodoros == 1 {
+		return fmt.Sprintf("Completed 1 pomodoro, focused time: %s.", strings.TrimSpace(helpers.FormatSpendTime(focusedSeconds)))
+	}
+
+	return fmt.Sprintf("Completed %d pomodoros, focused time: %s.", completedPomodoros, strings.TrimSpace(helpers.FormatSpendTime(focusedSeconds)))
+}
+
+// Applies the control typed in the timer mode, e.g. constants.TIMER_PAUSE. Returns whether the timer has ended,
+// and a message for the user if the control was refused or is unknown
+func (cs *CountdownService) Control(control string) (bool, string) {
+	sendSignal(cs.ActivityChan, cs.DoneChan) // Any input means the user is active
+
+	switch control {
+	case constants.TIMER_PAUSE:
+		sendSignal(cs.PauseChan, cs.DoneChan)
+	case constants.TIMER_RESUME:
+		sendSignal(cs.ResumeChan, cs.DoneChan)
+	case constants.TIMER_KEEP_IDLE, constants.TIMER_DISCARD_IDLE:
+		select {
+		case cs.IdleDecisionChan <- control == constants.TIMER_KEEP_IDLE:
+		case <-cs.DoneChan:
+		}
+	case constants.TIMER_STOP:
+		if cs.IsIdlePending() {
+			return false, "Type (k)eep or (d)iscard the idle time before stopping the timer."
+		}
+		sendSignal(cs.StopChan, cs.DoneChan) // Stop and update the task time
+		<-cs.DoneChan                        // Wait until the elapsed time is saved
+		return true, ""
+	case constants.TIMER_EXIT:
+		sendSignal(cs.ExitChan, cs.DoneChan) // Exit without updating the task time
+		<-cs.DoneChan
+		return true, "Exiting timer mode without saving time."
+	default:
+		return false, "Unknown command. Use (p)ause, (r)esume, (s)top, or (e)xit."
+	}
+
+	return false, ""
+}
+
+// Checks if the timer has ended
+func (cs *CountdownService) IsDone() bool {
+	select {
+	case <-cs.DoneChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// Sends the signal to the timer unless the timer has already finished
+func sendSignal(signalChan chan bool, doneChan chan bool) {
+	select {
+	case signalChan <- true:
+	case <-doneChan:
+	}
 }
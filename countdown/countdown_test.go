@@ -0,0 +1,368 @@
+package countdown
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MuradIsayev/todo-tracker/clock"
+	"github.com/MuradIsayev/todo-tracker/goal"
+	"github.com/MuradIsayev/todo-tracker/notifier"
+	"github.com/MuradIsayev/todo-tracker/service"
+	"github.com/MuradIsayev/todo-tracker/session"
+	"github.com/MuradIsayev/todo-tracker/task"
+	"github.com/MuradIsayev/todo-tracker/timer"
+)
+
+const testPid = 4242
+
+// recordingTimerStore persists the timer in a temporary file and records the calls of the countdown
+type recordingTimerStore struct {
+	*timer.TimerService
+	mutex    sync.Mutex
+	calls    []string
+	startErr error
+}
+
+func (s *recordingTimerStore) record(call string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.calls = append(s.calls, call)
+}
+
+func (s *recordingTimerStore) Start(task *task.Task, pid int, limitSeconds int) (*timer.ActiveTimer, error) {
+	if s.startErr != nil {
+		return nil, s.startErr
+	}
+
+	s.record(fmt.Sprintf("start task=%d pid=%d limit=%d", task.Id, pid, limitSeconds))
+	return s.TimerService.Start(task, pid, limitSeconds)
+}
+
+func (s *recordingTimerStore) Pause() (*timer.ActiveTimer, error) {
+	s.record("pause")
+	return s.TimerService.Pause()
+}
+
+func (s *recordingTimerStore) Resume() (*timer.ActiveTimer, error) {
+	s.record("resume")
+	return s.TimerService.Resume()
+}
+
+func (s *recordingTimerStore) Adjust(seconds int) (*timer.ActiveTimer, error) {
+	s.record(fmt.Sprintf("adjust %d", seconds))
+	return s.TimerService.Adjust(seconds)
+}
+
+func (s *recordingTimerStore) DiscardOwned(pid int, taskId int) error {
+	s.record(fmt.Sprintf("discard task=%d pid=%d", taskId, pid))
+	return s.TimerService.DiscardOwned(pid, taskId)
+}
+
+func (s *recordingTimerStore) Calls() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string{}, s.calls...)
+}
+
+// fakeTimeRecorder records the focused time saved to the tasks and projects
+type fakeTimeRecorder struct {
+	mutex     sync.Mutex
+	durations []int
+	sessions  []string
+}
+
+func (r *fakeTimeRecorder) DeleteAllTasks(projectId string, shouldAlterTasksCounter bool) error {
+	return nil
+}
+
+func (r *fakeTimeRecorder) DeleteTasksByProjectId(projectId string) error {
+	return nil
+}
+
+func (r *fakeTimeRecorder) UpdateTaskTimer(taskId int, newDuration int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.durations = append(r.durations, newDuration)
+
+	return nil
+}
+
+func (r *fakeTimeRecorder) DeleteAllProjects() error {
+	return nil
+}
+
+func (r *fakeTimeRecorder) DeleteProjectById(projectId string) error {
+	return nil
+}
+
+func (r *fakeTimeRecorder) UpdateProjectTimer(projectId int, newDuration int) error {
+	return nil
+}
+
+func (r *fakeTimeRecorder) RecordSession(taskId, projectId int, duration int, segments []session.Segment) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, segment := range segments {
+		r.sessions = append(r.sessions, fmt.Sprintf("%s-%s", segment.StartedAt.Format(time.TimeOnly), segment.EndedAt.Format(time.TimeOnly)))
+	}
+
+	return nil
+}
+
+func (r *fakeTimeRecorder) Sessions() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]string{}, r.sessions...)
+}
+
+func (r *fakeTimeRecorder) Durations() []int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]int{}, r.durations...)
+}
+
+// noGoals is a goal manager without any goal
+type noGoals struct{}
+
+func (noGoals) FindProjectGoalProgress(projectId int) ([]goal.GoalProgress, error) {
+	return nil, nil
+}
+
+func (noGoals) CollectReachedGoals(goalProgress []goal.GoalProgress, additionalSeconds int) []goal.Goal {
+	return nil
+}
+
+type countdownTest struct {
+	clock    *clock.FakeClock
+	store    *recordingTimerStore
+	recorder *fakeTimeRecorder
+	cs       *CountdownService
+	task     *task.Task
+
+	messages  []string
+	updates   chan string // Display updates not yet awaited by waitForMessage
+	collected chan bool
+	result    chan error
+}
+
+func newCountdownTest(t *testing.T) *countdownTest {
+	fakeClock := clock.NewFakeClock(time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC))
+	store := &recordingTimerStore{TimerService: timer.NewTimerService(filepath.Join(t.TempDir(), "timer.json"), nil, nil, fakeClock)}
+	recorder := &fakeTimeRecorder{}
+	manager := service.NewManager(recorder, recorder, recorder)
+
+	return &countdownTest{
+		clock:     fakeClock,
+		store:     store,
+		recorder:  recorder,
+		cs:        NewCountdownService(nil, manager, store, &notifier.SilentNotifier{}, noGoals{}, fakeClock, testPid),
+		task:      &task.Task{Id: 7, ProjectId: 1, Name: "Write tests"},
+		updates:   make(chan string, 10000),
+		collected: make(chan bool),
+		result:    make(chan error, 1),
+	}
+}
+
+// Runs the timer in a goroutine as the timer mode does, collecting its display updates until it ends
+func (ct *countdownTest) start(startTimer func() error) {
+	go func() {
+		defer close(ct.collected)
+		for {
+			select {
+			case message := <-ct.cs.DisplayChan:
+				ct.messages = append(ct.messages, message)
+				ct.updates <- message
+			case <-ct.cs.DoneChan:
+				return
+			}
+		}
+	}()
+
+	go func() {
+		ct.result <- startTimer()
+	}()
+}
+
+// Waits for the display update containing the text, e.g. the confirmation that a control has been applied
+func (ct *countdownTest) waitForMessage(text string) {
+	for message := range ct.updates {
+		if strings.Contains(message, text) {
+			return
+		}
+	}
+}
+
+// Waits until the timer has ended and returns its error
+func (ct *countdownTest) wait() error {
+	<-ct.collected
+	return <-ct.result
+}
+
+func (ct *countdownTest) lastMessage() string {
+	if len(ct.messages) == 0 {
+		return ""
+	}
+
+	return ct.messages[len(ct.messages)-1]
+}
+
+func assertCalls(t *testing.T, got []string, want []string) {
+	t.Helper()
+
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("timer store calls = %q, want %q", got, want)
+	}
+}
+
+func assertSessions(t *testing.T, got []string, want []string) {
+	t.Helper()
+
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("recorded sessions = %q, want %q", got, want)
+	}
+}
+
+func assertDurations(t *testing.T, got []int, want []int) {
+	t.Helper()
+
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("saved durations = %v, want %v", got, want)
+	}
+}
+
+func TestCountdownPauseResumeStop(t *testing.T) {
+	ct := newCountdownTest(t)
+	ct.start(func() error {
+		return ct.cs.StartCountdown(ct.task, 25)
+	})
+	ct.clock.WaitForTickers(1)
+
+	ct.clock.Advance(10 * time.Second)
+	ct.cs.PauseChan <- true
+	ct.waitForMessage("Timer paused")
+	ct.clock.Advance(30 * time.Second) // Paused time is not counted
+	ct.cs.ResumeChan <- true
+	ct.waitForMessage("Timer resumed")
+	ct.clock.Advance(5 * time.Second)
+	ct.cs.StopChan <- true
+
+	if err := ct.wait(); err != nil {
+		t.Fatalf("countdown returned %v", err)
+	}
+
+	assertDurations(t, ct.recorder.Durations(), []int{15})
+	assertSessions(t, ct.recorder.Sessions(), []string{"09:00:00-09:00:10", "09:00:40-09:00:45"})
+	assertCalls(t, ct.store.Calls(), []string{
+		fmt.Sprintf("start task=7 pid=%d limit=1500", testPid),
+		"pause",
+		"resume",
+		fmt.Sprintf("discard task=7 pid=%d", testPid),
+	})
+
+	if activeTimer, _ := ct.store.Load(); activeTimer != nil {
+		t.Errorf("persisted timer = %+v, want it to be removed", activeTimer)
+	}
+
+	if !strings.Contains(ct.lastMessage(), "Countdown stopped early") {
+		t.Errorf("last message = %q, want the countdown to be stopped early", ct.lastMessage())
+	}
+}
+
+func TestCountdownCompletesAtLimit(t *testing.T) {
+	ct := newCountdownTest(t)
+	ct.start(func() error {
+		return ct.cs.StartCountdown(ct.task, 1)
+	})
+	ct.clock.WaitForTickers(1)
+
+	ct.clock.Advance(90 * time.Second) // The ticks after the limit are not received
+
+	if err := ct.wait(); err != nil {
+		t.Fatalf("countdown returned %v", err)
+	}
+
+	assertDurations(t, ct.recorder.Durations(), []int{60})
+	assertSessions(t, ct.recorder.Sessions(), []string{"09:00:00-09:01:00"})
+
+	if !strings.Contains(ct.lastMessage(), "Countdown complete") {
+		t.Errorf("last message = %q, want the countdown to be complete", ct.lastMessage())
+	}
+}
+
+func TestStopwatchExitSavesNothing(t *testing.T) {
+	ct := newCountdownTest(t)
+	ct.start(func() error {
+		return ct.cs.StartStopwatch(ct.task)
+	})
+	ct.clock.WaitForTickers(1)
+
+	ct.clock.Advance(2 * time.Hour)
+	ct.cs.ExitChan <- true
+
+	if err := ct.wait(); err != nil {
+		t.Fatalf("stopwatch returned %v", err)
+	}
+
+	assertDurations(t, ct.recorder.Durations(), nil)
+	assertCalls(t, ct.store.Calls(), []string{
+		fmt.Sprintf("start task=7 pid=%d limit=0", testPid),
+		fmt.Sprintf("discard task=7 pid=%d", testPid),
+	})
+}
+
+func TestCountdownStartErrorKeepsThePersistedTimer(t *testing.T) {
+	ct := newCountdownTest(t)
+	ct.store.startErr = errors.New("a timer is already running for the task \"Other\"")
+	ct.start(func() error {
+		return ct.cs.StartCountdown(ct.task, 25)
+	})
+
+	err := ct.wait()
+	if err == nil || !strings.Contains(err.Error(), "a timer is already running") {
+		t.Fatalf("countdown returned %v, want the start error", err)
+	}
+
+	assertDurations(t, ct.recorder.Durations(), nil)
+	assertCalls(t, ct.store.Calls(), nil)
+}
+
+func TestCountdownDetectsSuspendWithoutIdleDetection(t *testing.T) {
+	ct := newCountdownTest(t)
+	ct.start(func() error {
+		return ct.cs.StartCountdown(ct.task, 25)
+	})
+	ct.clock.WaitForTickers(1)
+
+	ct.clock.Advance(10 * time.Second)
+	ct.clock.Set(ct.clock.Now().Add(10 * time.Minute)) // No tick is delivered while the system is suspended
+	ct.clock.Advance(1 * time.Second)
+
+	// The suspended interval waits for the keep/discard decision, even with the idle detection disabled
+	ct.cs.IdleDecisionChan <- false
+	ct.clock.Advance(4 * time.Second)
+	ct.cs.StopChan <- true
+
+	if err := ct.wait(); err != nil {
+		t.Fatalf("countdown returned %v", err)
+	}
+
+	assertDurations(t, ct.recorder.Durations(), []int{15})
+	assertCalls(t, ct.store.Calls(), []string{
+		fmt.Sprintf("start task=7 pid=%d limit=1500", testPid),
+		"pause",
+		"adjust -600",
+		"resume",
+		fmt.Sprintf("discard task=7 pid=%d", testPid),
+	})
+}
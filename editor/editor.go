@@ -0,0 +1,364 @@
+package editor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/MuradIsayev/todo-tracker/cli"
+	"github.com/MuradIsayev/todo-tracker/helpers"
+	"github.com/MuradIsayev/todo-tracker/terminal"
+	"github.com/mattn/go-runewidth"
+)
+
+// Returned by ReadLine when the line is cancelled with Ctrl-C
+var ErrInterrupted = errors.New("interrupted")
+
+// Maximum number of completion candidates listed below the prompt
+const maxListedCandidates = 20
+
+// Editor reads lines from the terminal with cursor movement, history and tab completion.
+// When the input is not a terminal it reads plain lines
+type Editor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	terminal bool
+
+	// Entries browsed with the up and down keys and searched with Ctrl-R, optional
+	History *History
+	// Returns the completions of the last of the words, which is empty if the cursor follows a space, optional
+	Complete func(words []string) []cli.Completion
+}
+
+// line is the state of the line being edited
+type line struct {
+	prompt string
+	buffer []rune
+	cursor int
+	width  int
+}
+
+func NewEditor(in *bufio.Reader, out io.Writer, terminal bool) *Editor {
+	return &Editor{in: in, out: out, terminal: terminal}
+}
+
+// Reads a line after printing the prompt. Returns io.EOF at the end of the input or on Ctrl-D, and ErrInterrupted on Ctrl-C
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	return e.EditLine(prompt, "")
+}
+
+// Reads a line like ReadLine, starting with the text already entered (e.g. the current name of a task to rename it)
+func (e *Editor) EditLine(prompt string, text string) (string, error) {
+	if !e.terminal {
+		return e.readPlainLine(prompt)
+	}
+
+	restore, err := terminal.EnableRawMode()
+	if err != nil {
+		return e.readPlainLine(prompt)
+	}
+	defer restore()
+
+	buffer := []rune(text)
+
+	return e.edit(&line{prompt: prompt, buffer: buffer, cursor: len(buffer), width: helpers.TerminalWidth()})
+}
+
+func (e *Editor) readPlainLine(prompt string) (string, error) {
+	fmt.Fprint(e.out, prompt)
+
+	input, err := e.in.ReadString('\n')
+	if err != nil && input == "" {
+		return "", err
+	}
+
+	return strings.TrimRight(input, "\r\n"), nil
+}
+
+func (e *Editor) edit(l *line) (string, error) {
+	history := e.historyEntries()
+	historyIndex := len(history)
+	draft := ""
+
+	e.refresh(l)
+
+	for {
+		key, err := terminal.ReadKey(e.in)
+		if err != nil {
+			return "", err
+		}
+
+		if key == terminal.KeyCtrlR {
+			if key, err = e.reverseSearch(l, history); err != nil {
+				return "", err
+			}
+		}
+
+		switch key {
+		case terminal.KeyEnter, terminal.KeyLineFeed:
+			fmt.Fprint(e.out, "\r\n")
+			return string(l.buffer), nil
+		case terminal.KeyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", ErrInterrupted
+		case terminal.KeyCtrlD:
+			if len(l.buffer) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			l.delete(l.cursor, l.cursor+1)
+		case terminal.KeyDelete:
+			l.delete(l.cursor, l.cursor+1)
+		case terminal.KeyBackspace, terminal.KeyCtrlH:
+			l.delete(l.cursor-1, l.cursor)
+		case terminal.KeyLeft, terminal.KeyCtrlB:
+			l.cursor = max(l.cursor-1, 0)
+		case terminal.KeyRight, terminal.KeyCtrlF:
+			l.cursor = min(l.cursor+1, len(l.buffer))
+		case terminal.KeyHome, terminal.KeyCtrlA:
+			l.cursor = 0
+		case terminal.KeyEnd, terminal.KeyCtrlE:
+			l.cursor = len(l.buffer)
+		case terminal.KeyCtrlK:
+			l.delete(l.cursor, len(l.buffer))
+		case terminal.KeyCtrlU:
+			l.delete(0, l.cursor)
+		case terminal.KeyCtrlW:
+			l.delete(l.previousWordStart(), l.cursor)
+		case terminal.KeyCtrlL:
+			fmt.Fprint(e.out, "\033[H\033[2J")
+		case terminal.KeyUp, terminal.KeyCtrlP:
+			if historyIndex > 0 {
+				if historyIndex == len(history) {
+					draft = string(l.buffer)
+				}
+				historyIndex--
+				l.set(history[historyIndex])
+			}
+		case terminal.KeyDown, terminal.KeyCtrlN:
+			if historyIndex < len(history) {
+				historyIndex++
+				if historyIndex == len(history) {
+					l.set(draft)
+				} else {
+					l.set(history[historyIndex])
+				}
+			}
+		case terminal.KeyTab:
+			e.complete(l)
+		default:
+			if key >= ' ' && unicode.IsPrint(key) {
+				l.insert([]rune{key})
+			}
+		}
+
+		e.refresh(l)
+	}
+}
+
+func (e *Editor) historyEntries() []string {
+	if e.History == nil {
+		return nil
+	}
+
+	return e.History.Entries()
+}
+
+// Redraws the line, scrolling it horizontally when it does not fit the terminal
+func (e *Editor) refresh(l *line) {
+	start, end := 0, len(l.buffer)
+
+	available := l.width - runewidth.StringWidth(l.prompt) - 1
+	if l.width > 0 && available > 0 {
+		for runewidth.StringWidth(string(l.buffer[start:l.cursor])) > available {
+			start++
+		}
+		for runewidth.StringWidth(string(l.buffer[start:end])) > available {
+			end--
+		}
+	}
+
+	fmt.Fprintf(e.out, "\r\033[K%s%s", l.prompt, string(l.buffer[start:end]))
+	if back := runewidth.StringWidth(string(l.buffer[l.cursor:end])); back > 0 {
+		fmt.Fprintf(e.out, "\033[%dD", back)
+	}
+}
+
+// Searches the history backwards for the typed text, until a key other than a character, Backspace or Ctrl-R is pressed.
+// Returns that key, which is handled on the found line; Escape, Ctrl-G and Ctrl-C restore the line
+func (e *Editor) reverseSearch(l *line, history []string) (rune, error) {
+	original := string(l.buffer)
+	query := []rune{}
+	matchIndex := len(history)
+	failing := false
+
+	find := func(from int) int {
+		for i := min(from, len(history)-1); i >= 0; i-- {
+			if strings.Contains(history[i], string(query)) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for {
+		match := ""
+		if matchIndex < len(history) {
+			match = history[matchIndex]
+		}
+
+		label := "reverse-i-search"
+		if failing {
+			label = "failing " + label
+		}
+		fmt.Fprintf(e.out, "\r\033[K(%s)`%s': %s", label, string(query), match)
+
+		key, err := terminal.ReadKey(e.in)
+		if err != nil {
+			return 0, err
+		}
+
+		switch {
+		case key == terminal.KeyCtrlR:
+			if index := find(matchIndex - 1); index >= 0 {
+				matchIndex, failing = index, false
+			} else {
+				failing = true
+			}
+		case key == terminal.KeyBackspace || key == terminal.KeyCtrlH:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+			}
+			matchIndex, failing = len(history), false
+			if index := find(len(history) - 1); index >= 0 && len(query) > 0 {
+				matchIndex = index
+			}
+		case key == terminal.KeyEscape || key == terminal.KeyCtrlG || key == terminal.KeyCtrlC:
+			l.set(original)
+			return terminal.KeyUnknown, nil
+		case key >= ' ' && unicode.IsPrint(key):
+			query = append(query, key)
+			if index := find(matchIndex); index >= 0 {
+				matchIndex, failing = index, false
+			} else {
+				failing = true
+			}
+		default:
+			if match != "" {
+				l.set(match)
+			}
+			return key, nil
+		}
+	}
+}
+
+// Completes the word before the cursor: a single candidate is inserted, several candidates are
+// completed to their common prefix or listed below the line with their descriptions as hints
+func (e *Editor) complete(l *line) {
+	if e.Complete == nil {
+		return
+	}
+
+	before := string(l.buffer[:l.cursor])
+	words := strings.Fields(before)
+	if before == "" || unicode.IsSpace(l.buffer[l.cursor-1]) {
+		words = append(words, "")
+	}
+
+	current := []rune(words[len(words)-1])
+	completions := e.Complete(words)
+
+	switch len(completions) {
+	case 0:
+		fmt.Fprint(e.out, "\a")
+	case 1:
+		l.replaceWord(len(current), completions[0].Value+" ")
+	default:
+		prefix := commonPrefix(completions)
+		if len([]rune(prefix)) > len(current) {
+			l.replaceWord(len(current), prefix)
+			return
+		}
+
+		e.listCandidates(completions)
+	}
+}
+
+func commonPrefix(completions []cli.Completion) string {
+	prefix := completions[0].Value
+	for _, completion := range completions[1:] {
+		for !strings.HasPrefix(completion.Value, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+
+	return prefix
+}
+
+// Lists the candidates below the line, each with its description (e.g. the name of a task) as a hint
+func (e *Editor) listCandidates(completions []cli.Completion) {
+	valueWidth := 0
+	for _, completion := range completions {
+		valueWidth = max(valueWidth, runewidth.StringWidth(completion.Value))
+	}
+
+	fmt.Fprint(e.out, "\r\n")
+	for i, completion := range completions {
+		if i == maxListedCandidates {
+			fmt.Fprintf(e.out, "  ... and %d more\r\n", len(completions)-maxListedCandidates)
+			break
+		}
+
+		fmt.Fprintf(e.out, "  %s  %s\r\n", runewidth.FillRight(completion.Value, valueWidth), completion.Description)
+	}
+}
+
+func (l *line) insert(runes []rune) {
+	l.buffer = append(l.buffer[:l.cursor], append(runes, l.buffer[l.cursor:]...)...)
+	l.cursor += len(runes)
+}
+
+// Deletes the runes in [from, to), bounded to the line
+func (l *line) delete(from, to int) {
+	from, to = max(from, 0), min(to, len(l.buffer))
+	if from >= to {
+		return
+	}
+
+	l.buffer = append(l.buffer[:from], l.buffer[to:]...)
+	if l.cursor > to {
+		l.cursor -= to - from
+	} else if l.cursor > from {
+		l.cursor = from
+	}
+}
+
+// Replaces the line, moving the cursor to its end
+func (l *line) set(text string) {
+	l.buffer = []rune(text)
+	l.cursor = len(l.buffer)
+}
+
+// Replaces the word of the given length before the cursor with the text
+func (l *line) replaceWord(length int, text string) {
+	l.delete(l.cursor-length, l.cursor)
+	l.insert([]rune(text))
+}
+
+// Returns the start of the word before the cursor, skipping the spaces that follow it
+func (l *line) previousWordStart() int {
+	i := l.cursor
+	for i > 0 && unicode.IsSpace(l.buffer[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(l.buffer[i-1]) {
+		i--
+	}
+
+	return i
+}
@@ -0,0 +1,97 @@
+package editor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// History holds the entered lines, oldest first, persisted in a file with one line per entry
+type History struct {
+	filePath   string
+	maxEntries int
+	entries    []string
+}
+
+// Loads the history from the file, which may not exist yet. Only the last maxEntries lines are kept
+func LoadHistory(filePath string, maxEntries int) (*History, error) {
+	history := &History{filePath: filePath, maxEntries: maxEntries}
+
+	file, err := os.Open(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read history: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			history.entries = append(history.entries, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read history: %v", err)
+	}
+
+	history.trim()
+
+	return history, nil
+}
+
+// Returns the entries, oldest first
+func (h *History) Entries() []string {
+	return h.entries
+}
+
+// Adds the line to the history and appends it to the file. Empty lines and repetitions of the last entry are skipped
+func (h *History) Add(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" || (len(h.entries) > 0 && h.entries[len(h.entries)-1] == line) {
+		return nil
+	}
+
+	h.entries = append(h.entries, line)
+
+	if err := os.MkdirAll(filepath.Dir(h.filePath), 0755); err != nil {
+		return fmt.Errorf("cannot write history: %v", err)
+	}
+
+	// Once the history is full the file is rewritten without the dropped entries, so it does not grow forever
+	if h.trim() {
+		data := strings.Join(h.entries, "\n") + "\n"
+		if err := os.WriteFile(h.filePath, []byte(data), 0644); err != nil {
+			return fmt.Errorf("cannot write history: %v", err)
+		}
+		return nil
+	}
+
+	file, err := os.OpenFile(h.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("cannot write history: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintln(file, line); err != nil {
+		return fmt.Errorf("cannot write history: %v", err)
+	}
+
+	return nil
+}
+
+// Drops the oldest entries above the maximum, and reports whether any was dropped
+func (h *History) trim() bool {
+	if h.maxEntries <= 0 || len(h.entries) <= h.maxEntries {
+		return false
+	}
+
+	h.entries = h.entries[len(h.entries)-h.maxEntries:]
+
+	return true
+}
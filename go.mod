@@ -4,4 +4,4 @@ go 1.22.1
 
 require github.com/olekukonko/tablewriter v0.0.5
 
-require github.com/mattn/go-runewidth v0.0.9 // indirect
+require github.com/mattn/go-runewidth v0.0.9
@@ -0,0 +1,283 @@
+package goal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MuradIsayev/todo-tracker/base"
+	"github.com/MuradIsayev/todo-tracker/clock"
+	"github.com/MuradIsayev/todo-tracker/helpers"
+	"github.com/MuradIsayev/todo-tracker/session"
+)
+
+// GOAL PERIODS:
+const (
+	DAILY  = "daily"
+	WEEKLY = "weekly"
+)
+
+// Goal is a target of focused time per day or per week, for all projects (ProjectId=0) or a single project
+type Goal struct {
+	Id            int       `json:"id"`
+	Period        string    `json:"period"`
+	TargetSeconds int       `json:"targetSeconds"`
+	ProjectId     int       `json:"projectId"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+	LastReachedAt time.Time `json:"lastReachedAt"`
+}
+
+// GoalProgress is the focused time recorded towards the goal in its current period
+type GoalProgress struct {
+	Goal           Goal
+	FocusedSeconds int
+	PeriodStart    time.Time
+}
+
+// Checks if the goal is reached with the additional (not yet recorded) focused seconds
+func (p *GoalProgress) IsReached(additionalSeconds int) bool {
+	return p.FocusedSeconds+additionalSeconds >= p.Goal.TargetSeconds
+}
+
+// Checks if the reminder for the goal has already fired in the current period
+func (p *GoalProgress) IsReminded() bool {
+	return !p.Goal.LastReachedAt.Before(p.PeriodStart)
+}
+
+// Returns the progress in percent with the additional (not yet recorded) focused seconds
+func (p *GoalProgress) Percent(additionalSeconds int) int {
+	if p.Goal.TargetSeconds == 0 {
+		return 100
+	}
+
+	return (p.FocusedSeconds + additionalSeconds) * 100 / p.Goal.TargetSeconds
+}
+
+// GoalRecord is the machine-readable representation of a goal
+type GoalRecord struct {
+	Id            int       `json:"id"`
+	Period        string    `json:"period"`
+	TargetSeconds int       `json:"targetSeconds"`
+	ProjectId     int       `json:"projectId"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// GoalProgressRecord is the machine-readable representation of the progress of a goal
+type GoalProgressRecord struct {
+	Id             int       `json:"id"`
+	Period         string    `json:"period"`
+	ProjectId      int       `json:"projectId"`
+	PeriodStart    time.Time `json:"periodStart"`
+	TargetSeconds  int       `json:"targetSeconds"`
+	FocusedSeconds int       `json:"focusedSeconds"`
+	Percent        int       `json:"percent"`
+	Reached        bool      `json:"reached"`
+}
+
+func NewGoalRecord(goal Goal) GoalRecord {
+	return GoalRecord{
+		Id:            goal.Id,
+		Period:        goal.Period,
+		TargetSeconds: goal.TargetSeconds,
+		ProjectId:     goal.ProjectId,
+		CreatedAt:     goal.CreatedAt,
+		UpdatedAt:     goal.UpdatedAt,
+	}
+}
+
+func NewGoalProgressRecord(progress GoalProgress) GoalProgressRecord {
+	return GoalProgressRecord{
+		Id:             progress.Goal.Id,
+		Period:         progress.Goal.Period,
+		ProjectId:      progress.Goal.ProjectId,
+		PeriodStart:    progress.PeriodStart,
+		TargetSeconds:  progress.Goal.TargetSeconds,
+		FocusedSeconds: progress.FocusedSeconds,
+		Percent:        progress.Percent(0),
+		Reached:        progress.IsReached(0),
+	}
+}
+
+type GoalService struct {
+	baseService    *base.BaseService[Goal]
+	sessionService *session.SessionService
+	clock          clock.Clock
+}
+
+type GoalManager interface {
+	FindProjectGoalProgress(projectId int) ([]GoalProgress, error)
+	CollectReachedGoals(goalProgress []GoalProgress, additionalSeconds int) []Goal
+}
+
+func NewGoalService(filePath string, sessionService *session.SessionService, clock clock.Clock) *GoalService {
+	return &GoalService{
+		sessionService: sessionService,
+		clock:          clock,
+		baseService: &base.BaseService[Goal]{
+			FilePath: filePath,
+			Clock:    clock,
+		},
+	}
+}
+
+// Returns the start of the period (midnight for a day, Monday midnight for a week) containing the given time
+func PeriodStart(period string, now time.Time) time.Time {
+	if period == WEEKLY {
+		return helpers.StartOfWeek(now)
+	}
+
+	return helpers.StartOfDay(now)
+}
+
+// Returns the end of the period starting at the given time
+func PeriodEnd(period string, periodStart time.Time) time.Time {
+	if period == WEEKLY {
+		return periodStart.AddDate(0, 0, 7)
+	}
+
+	return periodStart.AddDate(0, 0, 1)
+}
+
+// Sets the goal for the period and the project, replacing the existing goal for the same period and project.
+// Returns true if an existing goal was replaced
+func (s *GoalService) SetGoal(period string, targetSeconds int, projectId int) (bool, error) {
+	if period != DAILY && period != WEEKLY {
+		return false, fmt.Errorf("invalid period %q, expected %q or %q", period, DAILY, WEEKLY)
+	}
+
+	if targetSeconds <= 0 {
+		return false, fmt.Errorf("target must be positive")
+	}
+
+	goals := []Goal{}
+	if err := s.baseService.ReadFromFile(&goals); err != nil {
+		return false, err
+	}
+
+	now := s.clock.Now()
+	for i, goal := range goals {
+		if goal.Period == period && goal.ProjectId == projectId {
+			goals[i].TargetSeconds = targetSeconds
+			goals[i].UpdatedAt = now
+			goals[i].LastReachedAt = time.Time{}
+
+			return true, s.baseService.WriteToFile(goals)
+		}
+	}
+
+	goal := Goal{
+		Id:            s.baseService.GetNextID(goals),
+		Period:        period,
+		TargetSeconds: targetSeconds,
+		ProjectId:     projectId,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	goals = append(goals, goal)
+
+	return false, s.baseService.WriteToFile(goals)
+}
+
+func (s *GoalService) DeleteGoal(id string) error {
+	return s.baseService.DeleteItemById(id)
+}
+
+// Finds the progress of all goals in their current period
+func (s *GoalService) FindGoalProgress() ([]GoalProgress, error) {
+	goals := []Goal{}
+	if err := s.baseService.ReadFromFile(&goals); err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	progress := []GoalProgress{}
+	for _, goal := range goals {
+		periodStart := PeriodStart(goal.Period, now)
+
+		focusedSeconds, err := s.sessionService.SumFocusedTime(periodStart, PeriodEnd(goal.Period, periodStart), goal.ProjectId)
+		if err != nil {
+			return nil, err
+		}
+
+		progress = append(progress, GoalProgress{Goal: goal, FocusedSeconds: focusedSeconds, PeriodStart: periodStart})
+	}
+
+	return progress, nil
+}
+
+// Finds the progress of the goals that the focused time on the project counts towards
+func (s *GoalService) FindProjectGoalProgress(projectId int) ([]GoalProgress, error) {
+	progress, err := s.FindGoalProgress()
+	if err != nil {
+		return nil, err
+	}
+
+	projectProgress := []GoalProgress{}
+	for _, p := range progress {
+		if p.Goal.ProjectId == 0 || p.Goal.ProjectId == projectId {
+			projectProgress = append(projectProgress, p)
+		}
+	}
+
+	return projectProgress, nil
+}
+
+// Marks the goals reached with the additional (not yet recorded) focused seconds and returns them.
+// A goal is returned only once per period, so its reminder fires only once
+func (s *GoalService) CollectReachedGoals(goalProgress []GoalProgress, additionalSeconds int) []Goal {
+	reachedGoals := []Goal{}
+	for i := range goalProgress {
+		p := &goalProgress[i]
+		if p.IsReminded() || !p.IsReached(additionalSeconds) {
+			continue
+		}
+
+		p.Goal.LastReachedAt = s.clock.Now()
+		if err := s.markGoalReached(p.Goal.Id); err != nil {
+			continue
+		}
+
+		reachedGoals = append(reachedGoals, p.Goal)
+	}
+
+	return reachedGoals
+}
+
+func (s *GoalService) markGoalReached(id int) error {
+	goals := []Goal{}
+	if err := s.baseService.ReadFromFile(&goals); err != nil {
+		return err
+	}
+
+	index, goal, err := s.baseService.FindItemById(goals, id)
+	if err != nil {
+		return err
+	}
+
+	goal.LastReachedAt = s.clock.Now()
+	goals[index] = *goal
+
+	return s.baseService.WriteToFile(goals)
+}
+
+// Describes the goal, e.g. "4 hours daily (project ID=3)"
+func DescribeGoal(goal Goal) string {
+	description := fmt.Sprintf("%s %s", strings.TrimSpace(helpers.FormatSpendTime(goal.TargetSeconds)), goal.Period)
+	if goal.ProjectId != 0 {
+		description += fmt.Sprintf(" (project ID=%d)", goal.ProjectId)
+	}
+
+	return description
+}
+
+func (s *GoalService) FindGoals() ([]Goal, error) {
+	goals := []Goal{}
+	if err := s.baseService.ReadFromFile(&goals); err != nil {
+		return nil, err
+	}
+
+	return goals, nil
+}
@@ -7,7 +7,11 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
 // Validates the ID and converts it to an integer
@@ -21,6 +25,56 @@ func ValidateIdAndConvertToInt(id string) (int, error) {
 	return strconv.Atoi(id)
 }
 
+// Largest number of IDs a range such as 1-100 may stand for
+const maxIdRangeLength = 10000
+
+// Parses comma-separated IDs and ranges, e.g. "3,5,8-12", into sorted IDs without duplicates
+func ParseIdList(value string) ([]int, error) {
+	seen := map[int]bool{}
+	ids := []int{}
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := ValidateIdAndConvertToInt(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID %q: %v", part, err)
+		}
+
+		to := from
+		if isRange {
+			if to, err = ValidateIdAndConvertToInt(last); err != nil {
+				return nil, fmt.Errorf("invalid range %q: %v", part, err)
+			}
+			if to < from {
+				return nil, fmt.Errorf("invalid range %q, the first ID is greater than the last", part)
+			}
+			if to-from >= maxIdRangeLength {
+				return nil, fmt.Errorf("range %q is longer than %d IDs", part, maxIdRangeLength)
+			}
+		}
+
+		for id := from; id <= to; id++ {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil, errors.New("no IDs given")
+	}
+
+	slices.Sort(ids)
+
+	return ids, nil
+}
+
 // Removes a file by its file path
 func RemoveFileByFilePath(filePath string) error {
 	err := os.Remove(filePath)
@@ -104,41 +158,74 @@ func FormatSpendTime(totalSpentTime int) string {
 	return formattedSpendTime
 }
 
-func BeepBeep() {
-	cmd := exec.Command("afplay", "/System/Library/Sounds/Glass.aiff") // Change to a different sound if needed
-	cmd.Run()
+// Returns midnight of the day containing the given time
+func StartOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Returns Monday midnight of the week containing the given time
+func StartOfWeek(t time.Time) time.Time {
+	dayStart := StartOfDay(t)
+	daysSinceMonday := (int(dayStart.Weekday()) + 6) % 7
+
+	return dayStart.AddDate(0, 0, -daysSinceMonday)
 }
 
-func DisplayHelp() {
-	fmt.Println("\nWelcome to Todo Tracker CLI in Go!")
-	fmt.Println("This tool helps you manage projects and tasks, set timers, and keep track of your progress.")
-
-	fmt.Println("\n**Available Commands**")
-	fmt.Println("-----------------------")
-	fmt.Println("1. **Project Management (Normal Mode)**")
-	fmt.Println("   - `add <project name>`  : Creates a new project with the given name.")
-	fmt.Println("   - `list`                   : Lists all current projects.")
-	fmt.Println("   - `delete <project ID> | --all`  : Deletes the specified project(s) and all its associated tasks.")
-	fmt.Println("   - `update <project ID> <new project name>` : Renames the specified project.")
-	fmt.Println("   - `mark <project ID> --done | --in-progress | --todo` : Marks the project status as done, in-progress, or to-do.")
-	fmt.Println("   - `repl <project ID>`                   : Enters the REPL mode for the specified project to manage tasks.")
-
-	fmt.Println("\n2. **Task Management (REPL Mode)**")
-	fmt.Println("Contains the same commands as project management, except for the following command")
-	fmt.Println("   - `t <task ID> <minutes>` : Starts a countdown timer for a specific task, and enters the Timer mode")
-	fmt.Println("   (Timer will countdown from the specified minutes)")
-
-	fmt.Println("\n3. **Timer Commands (Timer Mode)**")
-	fmt.Println("   - `s`                                     : Stops the active timer and saves the focused time.")
-	fmt.Println("   - `p`                                     : Pauses the active timer.")
-	fmt.Println("   - `r`                                     : Resumes the paused timer.")
-	fmt.Println("   - `e`                                     : Exits the timer mode and ignores the focused time.")
-
-	fmt.Println("\n4. **General Commands**")
-	fmt.Println("   - `help`                 : Shows this help message with command descriptions (Normal mode command).")
-	fmt.Println("   - `exit`       : Exits the REPL mode. (REPL mode command).")
-
-	fmt.Println("\n**Note**: For a full guide, see the README file or visit the project repository on GitHub.")
-	fmt.Println("Happy tracking!")
-	fmt.Println("-----------------------")
+// Formats the seconds as hours and minutes, e.g. 1h05m
+func FormatHoursMinutes(seconds int) string {
+	return fmt.Sprintf("%dh%02dm", seconds/3600, (seconds%3600)/60)
+}
+
+// Renders the percentage as a bar of the given width, e.g. [#####-----]
+func ProgressBar(percent int, width int) string {
+	filled := min(max(percent, 0), 100) * width / 100
+
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", width-filled) + "]"
+}
+
+// Checks if a process with the given PID is still running
+func IsProcessRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// Checks if the file is a terminal rather than a pipe or a regular file
+func IsTerminal(file *os.File) bool {
+	fileInfo, err := file.Stat()
+	return err == nil && fileInfo.Mode()&os.ModeCharDevice != 0
+}
+
+// Returns the width of the terminal in columns, or 0 if the output is not a terminal.
+// The COLUMNS environment variable takes precedence over the size reported by stty
+func TerminalWidth() int {
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width
+	}
+
+	if !IsTerminal(os.Stdout) {
+		return 0
+	}
+
+	sttyCommand := exec.Command("stty", "size")
+	sttyCommand.Stdin = os.Stdin
+	size, err := sttyCommand.Output()
+	if err != nil {
+		return 0
+	}
+
+	fields := strings.Fields(string(size))
+	if len(fields) != 2 {
+		return 0
+	}
+
+	width, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0
+	}
+
+	return width
 }
@@ -2,456 +2,1102 @@ package main
 
 import (
 	"bufio"
-	"flag"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
-
+	"time"
+
+	"github.com/MuradIsayev/todo-tracker/billing"
+	"github.com/MuradIsayev/todo-tracker/board"
+	"github.com/MuradIsayev/todo-tracker/chart"
+	"github.com/MuradIsayev/todo-tracker/cli"
+	"github.com/MuradIsayev/todo-tracker/clock"
+	"github.com/MuradIsayev/todo-tracker/config"
 	"github.com/MuradIsayev/todo-tracker/constants"
 	"github.com/MuradIsayev/todo-tracker/countdown"
+	"github.com/MuradIsayev/todo-tracker/editor"
+	"github.com/MuradIsayev/todo-tracker/goal"
 	"github.com/MuradIsayev/todo-tracker/helpers"
+	"github.com/MuradIsayev/todo-tracker/notifier"
+	"github.com/MuradIsayev/todo-tracker/output"
 	"github.com/MuradIsayev/todo-tracker/project"
+	"github.com/MuradIsayev/todo-tracker/query"
+	"github.com/MuradIsayev/todo-tracker/render"
+	"github.com/MuradIsayev/todo-tracker/report"
+	"github.com/MuradIsayev/todo-tracker/script"
+	"github.com/MuradIsayev/todo-tracker/search"
 	"github.com/MuradIsayev/todo-tracker/service"
-	"github.com/MuradIsayev/todo-tracker/status"
+	"github.com/MuradIsayev/todo-tracker/session"
+	"github.com/MuradIsayev/todo-tracker/stats"
 	"github.com/MuradIsayev/todo-tracker/task"
-	"github.com/olekukonko/tablewriter"
+	"github.com/MuradIsayev/todo-tracker/timer"
+	"github.com/MuradIsayev/todo-tracker/tui"
 )
 
-func startREPL(
-	circularDependencyManager *service.Manager,
-	projectId string,
-	projectName string,
-	taskService *task.TaskService,
-) {
-	fmt.Println("Welcome to the Task Management CLI for project:", projectName)
-	fmt.Println("Commands: add, list, update, delete, mark, (t)imer, exit")
+// Dependencies shared by the timer commands of the REPL mode
+type timerDependencies struct {
+	circularDependencyManager *service.Manager
+	timerService              *timer.TimerService
+	notifier                  notifier.Notifier
+	goalService               *goal.GoalService
+	clock                     clock.Clock
+	idleAfterMinutes          int
+}
+
+// Input shared by every prompt, so the input buffered by one prompt is not lost to the next
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// Set while a script is read from stdin, which leaves no input for the controls of the timer mode
+var stdinScriptRunning bool
+
+var errTimerModeInScript = fmt.Errorf("the timer mode reads its controls from stdin and cannot run in a script read from stdin, use `%s %s` instead", constants.PERSISTENT_TIMER, constants.TIMER_START)
+
+// Number of lines kept in the history of a project
+const maxHistoryEntries = 500
+
+func historyFilePath(projectId string) string {
+	return fmt.Sprintf("output/history/%s_%s", projectId, constants.HISTORY_FILE_NAME)
+}
+
+// replSession is the state of the REPL mode, whose active project is switched with the use command
+type replSession struct {
+	deps       *commandDependencies
+	lineEditor *editor.Editor
+	history    *editor.History
+}
+
+// Makes the project active: binds the task commands to it and loads its history
+func (s *replSession) use(project *project.Project) {
+	projectId := strconv.Itoa(project.Id)
+	s.deps.bindProject(projectId)
 
-	reader := bufio.NewReader(os.Stdin)
+	history, err := editor.LoadHistory(historyFilePath(projectId), maxHistoryEntries)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+
+	s.history = history
+	s.lineEditor.History = history
+}
+
+// Returns the prompt showing the name of the active project, which may have been renamed since it was activated
+func (s *replSession) prompt() string {
+	return fmt.Sprintf("%s >>> ", s.deps.projectService.FindProjectNameById(s.deps.projectId))
+}
 
-	// add projectId to the task service
-	taskService.AddProjectIdToTaskService(projectId)
+func startREPL(deps *commandDependencies, activeProject *project.Project, stopOnError bool) error {
+	session := &replSession{
+		deps:       deps,
+		lineEditor: editor.NewEditor(stdinReader, os.Stdout, helpers.IsTerminal(os.Stdin)),
+	}
+
+	registry := newREPLRegistry(session)
+	session.lineEditor.Complete = registry.Complete
+	session.use(activeProject)
+
+	// Commands piped or redirected into the REPL mode run as a script, without the welcome and the prompt
+	if !helpers.IsTerminal(os.Stdin) {
+		return runScript(stdinReader, "stdin", nil, stopOnError, func(args []string) error {
+			err := runCommandLine(registry, deps.renderer, args)
+			if errors.Is(err, errExitREPL) {
+				return script.ErrExit
+			}
+
+			return err
+		})
+	}
+
+	fmt.Println("Welcome to the Task Management CLI for project:", activeProject.Name)
+	fmt.Println("Commands:", strings.Join(registry.Names(), ", "))
 
 	for {
-		fmt.Print(">>> ")
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(input)
+		input, err := session.lineEditor.ReadLine(session.prompt())
+		if errors.Is(err, editor.ErrInterrupted) {
+			continue
+		}
+		if err != nil {
+			break
+		}
+
+		parts, err := cli.SplitArgs(input)
+		if err != nil {
+			printCommandError(err)
+			continue
+		}
+		if len(parts) == 0 {
+			continue
+		}
+
+		if session.history != nil {
+			if err := session.history.Add(input); err != nil {
+				fmt.Fprintln(os.Stderr, "Error:", err)
+			}
+		}
 
-		if input == "exit" {
+		err = runCommandLine(registry, deps.renderer, parts)
+		if errors.Is(err, errExitREPL) {
 			break
 		}
 
-		executeCommand(input, projectId, taskService, circularDependencyManager)
+		if err != nil {
+			printCommandError(err)
+		}
 	}
+
+	return nil
 }
 
-func executeCommand(input string, projectId string, taskService *task.TaskService, circularDependencyManager *service.Manager) {
-	parts := strings.Fields(input)
-	if len(parts) == 0 {
-		return
+// Runs the commands of the script, printing the error of every failing command with its location.
+// Returns an error if a command has failed, so the exit status reports it
+func runScript(in io.Reader, name string, variables map[string]string, stopOnError bool, runCommand func(args []string) error) error {
+	options := script.Options{
+		Name:        name,
+		StopOnError: stopOnError,
+		Variables:   variables,
+		OnError: func(location string, err error) {
+			fmt.Fprintf(os.Stderr, "%s: ", location)
+			printCommandError(err)
+		},
 	}
 
-	command := parts[0]
-	args := parts[1:]
-
-	switch command {
-	case constants.ADD:
-		handleAddCommand(args, taskService, projectId)
-	case constants.LIST:
-		handleListCommand(args, taskService)
-	case constants.UPDATE:
-		handleUpdateCommand(args, taskService)
-	case constants.DELETE:
-		handleDeleteCommand(args, taskService, projectId)
-	case constants.MARK:
-		handleMarkCommand(args, taskService)
-	case constants.TIMER:
-		handleCountdownCommand(args, taskService, circularDependencyManager)
-	default:
-		fmt.Println("Unknown command:", command)
-		fmt.Println("Expected 'add', 'list', 'update', 'delete', 'mark', 't' or 'exit' commands")
+	if in == io.Reader(stdinReader) {
+		stdinScriptRunning = true
+		defer func() { stdinScriptRunning = false }()
+	}
+
+	result, err := script.Run(in, options, runCommand)
+	if err != nil {
+		return err
+	}
+
+	if result.StoppedAt != "" {
+		return fmt.Errorf("script stopped at %s after the command failed", result.StoppedAt)
+	}
+
+	if result.Failed > 0 {
+		return fmt.Errorf("%d of %d commands failed", result.Failed, result.Commands)
 	}
+
+	return nil
 }
 
-func handleCountdownCommand(args []string, taskService *task.TaskService, circularDependencyManager *service.Manager) {
-	if len(args) != 3 {
-		fmt.Println("USAGE: t <task_id> --time <duration>")
-		return
+func handleRunCommand(ctx *cli.Context, registry *cli.Registry, renderer *render.Renderer) error {
+	variables, err := script.ParseVariables(ctx.Args[1:])
+	if err != nil {
+		return ctx.UsageError("%v", err)
+	}
+
+	scriptPath := ctx.Args[0]
+	in := io.Reader(stdinReader)
+	if scriptPath != "-" {
+		file, err := os.Open(scriptPath)
+		if err != nil {
+			return fmt.Errorf("cannot read script: %v", err)
+		}
+		defer file.Close()
+		in = file
 	}
 
-	countdownCommand := flag.NewFlagSet("TIMER", flag.ExitOnError)
-	timePtr := countdownCommand.Int("time", 1, "Specify the countdown duration in minutes")
+	return runScript(in, scriptPath, variables, ctx.Bool("stop-on-error"), func(args []string) error {
+		return runCommandLine(registry, renderer, args)
+	})
+}
+
+// Runs a command line of the REPL mode or of a script. The format set by its --output flag only applies to the command
+func runCommandLine(registry *cli.Registry, renderer *render.Renderer, args []string) error {
+	format := renderer.Format()
+	defer renderer.SetFormat(format)
 
-	if err := countdownCommand.Parse(args[1:]); err != nil {
-		fmt.Println("Error parsing countdown command:", err)
-		return
+	return registry.Run(os.Stdout, args)
+}
+
+func handleCountdownCommand(ctx *cli.Context, taskService *task.TaskService, timers *timerDependencies) error {
+	countdownService, task, err := newTaskCountdown(ctx.Args[0], ctx.Bool("silent"), ctx.Int("idle"), taskService, timers)
+	if err != nil {
+		return err
+	}
+
+	title := fmt.Sprintf("%d-minute countdown", ctx.Int("time"))
+	if ctx.Bool("stopwatch") {
+		title = "Stopwatch"
+	}
+
+	return runTimerMode(countdownService, title, func() error {
+		if ctx.Bool("stopwatch") {
+			return countdownService.StartStopwatch(task)
+		}
+		return countdownService.StartCountdown(task, ctx.Int("time"))
+	})
+}
+
+func handleStopwatchCommand(ctx *cli.Context, taskService *task.TaskService, timers *timerDependencies) error {
+	countdownService, task, err := newTaskCountdown(ctx.Args[0], ctx.Bool("silent"), ctx.Int("idle"), taskService, timers)
+	if err != nil {
+		return err
 	}
 
-	taskID := args[0]
-	countdownService := countdown.NewCountdownService(taskService, circularDependencyManager)
+	return runTimerMode(countdownService, "Stopwatch", func() error {
+		return countdownService.StartStopwatch(task)
+	})
+}
+
+func handlePomodoroCommand(ctx *cli.Context, taskService *task.TaskService, timers *timerDependencies) error {
+	config := countdown.PomodoroConfig{
+		WorkMinutes:       ctx.Int("work"),
+		ShortBreakMinutes: ctx.Int("short-break"),
+		LongBreakMinutes:  ctx.Int("long-break"),
+		LongBreakEvery:    ctx.Int("long-every"),
+		Cycles:            ctx.Int("cycles"),
+	}
+
+	if config.WorkMinutes <= 0 || config.ShortBreakMinutes < 0 || config.LongBreakMinutes < 0 || config.LongBreakEvery < 0 || config.Cycles <= 0 {
+		return ctx.UsageError("work interval and cycles must be positive, breaks cannot be negative")
+	}
+
+	countdownService, task, err := newTaskCountdown(ctx.Args[0], ctx.Bool("silent"), ctx.Int("idle"), taskService, timers)
+	if err != nil {
+		return err
+	}
+
+	return runTimerMode(countdownService, fmt.Sprintf("Pomodoro session of %d cycles", config.Cycles), func() error {
+		return countdownService.StartPomodoro(task, config)
+	})
+}
+
+// Finds the task and creates the countdown service for it, unless another timer is already running or a script is read from stdin
+func newTaskCountdown(
+	taskID string,
+	silent bool,
+	idleAfterMinutes int,
+	taskService *task.TaskService,
+	timers *timerDependencies,
+) (*countdown.CountdownService, *task.Task, error) {
+	if stdinScriptRunning {
+		return nil, nil, errTimerModeInScript
+	}
 
 	task, err := taskService.FindTaskById(taskID)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return nil, nil, err
 	}
 
-	var wg sync.WaitGroup
+	activeTimer, err := timers.timerService.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if activeTimer != nil {
+		return nil, nil, fmt.Errorf("a timer is already running for the task \"%s\", use 'timer stop' to stop it first", activeTimer.TaskName)
+	}
+
+	timerNotifier := timers.notifier
+	if silent {
+		timerNotifier = &notifier.SilentNotifier{}
+	}
+
+	// The focused time is saved through the task service the task was found with, which may be bound to another project than the shared one
+	countdownService := countdown.NewCountdownService(taskService, timers.circularDependencyManager.WithTaskService(taskService), timers.timerService, timerNotifier, timers.goalService, timers.clock, os.Getpid())
+	countdownService.IdleAfter = time.Duration(idleAfterMinutes) * time.Minute
+
+	return countdownService, task, nil
+}
+
+// Runs the timer and its controls: full-screen when the tool runs in a terminal, otherwise line by line until the timer is done.
+// Returns the error of the timer, e.g. if it cannot be started
+func runTimerMode(countdownService *countdown.CountdownService, title string, startTimer func() error) error {
+	if helpers.IsTerminal(os.Stdin) && helpers.IsTerminal(os.Stdout) {
+		lastMessage, err := tui.RunCountdown(stdinReader, os.Stdout, countdownService, title, startTimer)
+		if !errors.Is(err, tui.ErrRawMode) {
+			if err == nil {
+				fmt.Println(lastMessage)
+			}
+			return err
+		}
+	}
 
 	// Start the countdown in a separate goroutine
-	wg.Add(1)
+	timerErr := make(chan error, 1)
 	go func() {
-		defer wg.Done()
-		countdownService.StartCountdown(task, *timePtr)
+		timerErr <- startTimer()
 	}()
 
-	// Display timer updates without interrupting input
-	wg.Add(1)
+	// Print the timer updates as they come, until the timer has ended
+	var display sync.WaitGroup
+	display.Add(1)
 	go func() {
-		defer wg.Done()
-		for displayMsg := range countdownService.DisplayChan {
-			fmt.Print("\0337")                           // Save cursor position
-			fmt.Printf("\033[1A\033[2K\r%s", displayMsg) // Clear timer line, print new time
-			fmt.Print("\0338")                           // Restore cursor position
+		defer display.Done()
+		for {
+			select {
+			case displayMsg := <-countdownService.DisplayChan:
+				fmt.Println(displayMsg)
+			case <-countdownService.DoneChan:
+				return // The last update is sent before the timer ends, so it has been printed
+			}
 		}
 	}()
 
-	// Start a goroutine to read input commands
-	reader := bufio.NewReader(os.Stdin)
-	printControls()
+	// Waits until the last update is printed, then prints the message and returns the error of the timer
+	finish := func(message string) error {
+		display.Wait()
+		if message != "" {
+			fmt.Println(message)
+		}
+
+		return <-timerErr
+	}
+
+	fmt.Println("Controls: type (p)ause, (r)esume, (s)top, or (e)xit to control the timer.")
 
+	lineEditor := editor.NewEditor(stdinReader, os.Stdout, false)
 	for {
-		fmt.Print("T> ")
-		input, err := reader.ReadString('\n')
+		input, err := lineEditor.ReadLine("")
 		if err != nil {
-			fmt.Println("Error reading input:", err)
-			return
+			fmt.Fprintln(os.Stderr, "Error reading input:", err)
+
+			// The controls can no longer be read, so the timer is left without saving as in the full-screen mode
+			_, message := countdownService.Control(constants.TIMER_EXIT)
+			return finish(message)
 		}
-		input = strings.TrimSpace(strings.ToLower(input))
 
-		select {
-		case <-countdownService.DoneChan:
-			return
-		default:
-			// Handle user commands from input
-			switch input {
-			case constants.TIMER_PAUSE:
-				countdownService.PauseChan <- true
-			case constants.TIMER_RESUME:
-				countdownService.ResumeChan <- true
-			case constants.TIMER_STOP:
-				countdownService.StopChan <- true // Stop and update the task time
-				return
-			case constants.TIMER_EXIT:
-				fmt.Println("Exiting timer mode without saving time.")
-				countdownService.ExitChan <- true // Exit without updating the task time
-				return
-			default:
-				fmt.Print("\0337")                                                                         // Save cursor position
-				fmt.Printf("\033[2A\033[2K\rUnknown command. Use (p)ause, (r)esume, (s)top, or (e)xit.\n") // Clear and print controls
-				fmt.Print("\0338")                                                                         // Restore cursor position
-			}
+		if countdownService.IsDone() {
+			return finish("")
+		}
+
+		ended, message := countdownService.Control(strings.TrimSpace(strings.ToLower(input)))
+		if ended {
+			return finish(message)
+		}
+		if message != "" {
+			fmt.Println(message)
 		}
 	}
 }
 
-// Function to consistently display the controls
-func printControls() {
-	fmt.Print("\0337")                                                                                              // Save cursor position
-	fmt.Printf("\033[2A\033[2K\rControls: type (p)ause, (r)esume, (s)top, or (e)xit to control the countdown.\n\n") // Clear and print controls
-	fmt.Print("\0338")                                                                                              // Restore cursor position
+func handleAddCommand(ctx *cli.Context, taskService *task.TaskService, projectId string, renderer *render.Renderer) error {
+	taskName := strings.Join(ctx.Args, " ")
+	if _, err := taskService.CreateTask(projectId, taskName); err != nil {
+		return err
+	}
+
+	renderer.Message("Task created successfully")
+
+	return nil
 }
 
-func handleAddCommand(args []string, taskService *task.TaskService, projectId string) {
-	if len(args) < 1 {
-		fmt.Println("USAGE: add <task_name>")
-		return
+func handleListCommand(ctx *cli.Context, taskService *task.TaskService, renderer *render.Renderer) error {
+	statusFilter, _ := statusFromFlags(ctx)
+
+	tasks, err := taskService.FindTasks(statusFilter)
+	if err != nil {
+		return err
 	}
 
-	taskName := strings.Join(args, " ")
-	if err := taskService.CreateTask(projectId, taskName); err != nil {
-		fmt.Println("Error:", err)
+	return renderer.Tasks(tasks, taskTableConfig(ctx))
+}
+
+func handleUpdateCommand(ctx *cli.Context, taskService *task.TaskService, renderer *render.Renderer) error {
+	taskName := strings.Join(ctx.Args[1:], " ")
+	if err := taskService.UpdateTaskName(ctx.Args[0], taskName); err != nil {
+		return err
 	}
+
+	renderer.Message("Task name updated successfully")
+
+	return nil
 }
 
-func handleListCommand(args []string, taskService *task.TaskService) {
-	listCommand := flag.NewFlagSet(constants.LIST, flag.ExitOnError)
-	listDone := listCommand.Bool("done", false, "List tasks with status DONE")
-	listInProgress := listCommand.Bool("in-progress", false, "List tasks with status IN_PROGRESS")
-	listTodo := listCommand.Bool("todo", false, "List tasks with status TODO")
+func handleDeleteCommand(ctx *cli.Context, taskService *task.TaskService, projectId string, renderer *render.Renderer, clock clock.Clock) error {
+	if ctx.Bool("all") {
+		if len(ctx.Args) > 0 || ctx.IsSet("filter") {
+			return ctx.UsageError("--all cannot be combined with task IDs or --filter")
+		}
+
+		if err := taskService.DeleteAllTasks(projectId, true); err != nil {
+			return err
+		}
 
-	listCommand.Parse(args)
+		renderer.Message("Tasks deleted successfully")
+		return nil
+	}
 
-	var statusFilter status.ItemStatus
-	switch {
-	case *listDone:
-		statusFilter = status.DONE
-	case *listInProgress:
-		statusFilter = status.IN_PROGRESS
-	case *listTodo:
-		statusFilter = status.TODO
-	default:
-		statusFilter = -1
+	taskIds, err := selectTaskIds(ctx, taskService, clock)
+	if err != nil {
+		return err
+	}
+
+	if len(taskIds) == 0 {
+		renderer.Message("No tasks match the filter")
+		return nil
+	}
+
+	deletedTasks, err := taskService.DeleteTasks(taskIds, projectId)
+	if err != nil {
+		return err
 	}
 
-	if err := taskService.ListTasks(statusFilter); err != nil {
-		fmt.Println("Error:", err)
+	if len(deletedTasks) == 1 {
+		renderer.Message("Task deleted successfully")
+		return nil
 	}
+
+	renderer.Messagef("Deleted %d tasks: %s", len(deletedTasks), describeTasks(deletedTasks))
+
+	return nil
 }
 
-func handleUpdateCommand(args []string, taskService *task.TaskService) {
-	if len(args) < 2 {
-		fmt.Println("USAGE: update <task_id> <new task name>")
-		return
+func handleMarkCommand(ctx *cli.Context, taskService *task.TaskService, renderer *render.Renderer, clock clock.Clock) error {
+	taskStatus, ok := statusFromFlags(ctx)
+	if !ok {
+		return ctx.UsageError("expected --done, --in-progress or --todo")
+	}
+
+	taskIds, err := selectTaskIds(ctx, taskService, clock)
+	if err != nil {
+		return err
+	}
+
+	if len(taskIds) == 0 {
+		renderer.Message("No tasks match the filter")
+		return nil
+	}
+
+	changedTasks, err := taskService.UpdateTasksStatus(taskIds, taskStatus)
+	if err != nil {
+		return err
 	}
 
-	taskName := strings.Join(args[1:], " ")
-	if err := taskService.UpdateTaskName(args[0], taskName); err != nil {
-		fmt.Println("Error:", err)
+	if len(taskIds) == 1 {
+		renderer.Message("Task status updated successfully")
+		return nil
 	}
+
+	renderer.Messagef("Marked %d of %d tasks as %s", len(changedTasks), len(taskIds), taskStatus)
+	if len(changedTasks) > 0 {
+		renderer.Messagef("Changed: %s", describeTasks(changedTasks))
+	}
+	if unchanged := len(taskIds) - len(changedTasks); unchanged > 0 {
+		renderer.Messagef("%d task(s) already had the status", unchanged)
+	}
+
+	return nil
 }
 
-func handleDeleteCommand(args []string, taskService *task.TaskService, projectId string) {
-	deleteCommand := flag.NewFlagSet(constants.DELETE, flag.ExitOnError)
-	deleteAll := deleteCommand.Bool("all", false, "Delete all tasks")
-	deleteCommand.Parse(args)
+// Returns the IDs of the tasks selected by the arguments, such as 3,5,8-12, or by the query of --filter
+func selectTaskIds(ctx *cli.Context, taskService *task.TaskService, clock clock.Clock) ([]int, error) {
+	filter := ctx.String("filter")
+	if (filter == "") == (len(ctx.Args) == 0) {
+		return nil, ctx.UsageError("expected task IDs or --filter")
+	}
 
-	if *deleteAll {
-		if err := taskService.DeleteAllTasks(projectId, true); err != nil {
-			fmt.Println("Error:", err)
+	if filter == "" {
+		taskIds, err := helpers.ParseIdList(strings.Join(ctx.Args, ","))
+		if err != nil {
+			return nil, ctx.UsageError("%v", err)
 		}
-		return
+
+		return taskIds, nil
 	}
 
-	if len(deleteCommand.Args()) != 1 {
-		fmt.Println("USAGE: delete <task_id> | --all")
-		return
+	taskQuery, err := query.Parse(filter, clock.Now())
+	if err != nil {
+		return nil, ctx.UsageError("invalid --filter: %v", err)
+	}
+
+	tasks, err := taskService.FindTasks(-1)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := taskService.DeleteTask(deleteCommand.Args()[0], projectId); err != nil {
-		fmt.Println("Error:", err)
+	taskIds := []int{}
+	for _, t := range tasks {
+		if taskQuery.Match(t) {
+			taskIds = append(taskIds, t.Id)
+		}
 	}
+
+	return taskIds, nil
 }
 
-func handleMarkCommand(args []string, taskService *task.TaskService) {
-	if len(args) != 2 {
-		fmt.Println("USAGE: mark <task_id> --done | --in-progress | --todo")
-		return
+// Lists the IDs and names of the tasks for the summary of a batch operation
+func describeTasks(tasks []task.Task) string {
+	const maxListedTasks = 10
+
+	descriptions := []string{}
+	for i, t := range tasks {
+		if i == maxListedTasks {
+			descriptions = append(descriptions, fmt.Sprintf("and %d more", len(tasks)-maxListedTasks))
+			break
+		}
+
+		descriptions = append(descriptions, fmt.Sprintf("#%d %s", t.Id, t.Name))
 	}
 
-	taskID := args[0]
-	// Create a flag set for parsing the status flags
-	markCommand := flag.NewFlagSet("mark", flag.ExitOnError)
+	return strings.Join(descriptions, ", ")
+}
+
+func handleTagCommand(ctx *cli.Context, taskService *task.TaskService, renderer *render.Renderer) error {
+	if err := taskService.UpdateTaskTags(ctx.Args[0], ctx.Args[1:], ctx.Bool("remove")); err != nil {
+		return err
+	}
 
-	// Define the flags
-	markDone := markCommand.Bool("done", false, "Mark task as DONE")
-	markInProgress := markCommand.Bool("in-progress", false, "Mark task as IN_PROGRESS")
-	markTodo := markCommand.Bool("todo", false, "Mark task as TODO")
+	renderer.Message("Task tags updated successfully")
+
+	return nil
+}
+
+func handleDescribeCommand(ctx *cli.Context, taskService *task.TaskService, renderer *render.Renderer) error {
+	if err := taskService.UpdateTaskDescription(ctx.Args[0], strings.Join(ctx.Args[1:], " ")); err != nil {
+		return err
+	}
+
+	renderer.Message("Task description updated successfully")
+
+	return nil
+}
+
+func handleNoteCommand(ctx *cli.Context, taskService *task.TaskService, renderer *render.Renderer) error {
+	if err := taskService.AddTaskNote(ctx.Args[0], strings.Join(ctx.Args[1:], " ")); err != nil {
+		return err
+	}
+
+	renderer.Message("Task note added successfully")
+
+	return nil
+}
+
+func handleDueCommand(ctx *cli.Context, taskService *task.TaskService, renderer *render.Renderer) error {
+	if ctx.Bool("clear") == (len(ctx.Args) == 2) {
+		return ctx.UsageError("expected a due date or --clear")
+	}
+
+	var dueDate *time.Time
+	if !ctx.Bool("clear") {
+		parsedDate, err := time.ParseInLocation(constants.DAY_FORMAT, ctx.Args[1], time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid due date %s", ctx.Args[1])
+		}
+		dueDate = &parsedDate
+	}
+
+	if err := taskService.UpdateTaskDueDate(ctx.Args[0], dueDate); err != nil {
+		return err
+	}
+
+	renderer.Message("Task due date updated successfully")
+
+	return nil
+}
 
-	// Parse the remaining args after extracting the task ID
-	err := markCommand.Parse(args[1:])
+func handleFindCommand(ctx *cli.Context, taskService *task.TaskService, renderer *render.Renderer, clock clock.Clock) error {
+	taskQuery, err := query.Parse(strings.Join(ctx.Args, " "), clock.Now())
 	if err != nil {
-		fmt.Println("Error parsing flags:", err)
-		return
+		return ctx.UsageError("%v", err)
+	}
+
+	groups, err := taskService.FindMatchingTasks(taskQuery.Match)
+	if err != nil {
+		return err
+	}
+
+	return renderer.ProjectTasks(groups, taskTableConfig(ctx))
+}
+
+func handleRateCommand(ctx *cli.Context, projectService *project.ProjectService, renderer *render.Renderer) error {
+	hourlyRate, err := strconv.ParseFloat(ctx.Args[1], 64)
+	if err != nil {
+		return fmt.Errorf("invalid hourly rate %s", ctx.Args[1])
+	}
+
+	var currency string
+	if len(ctx.Args) > 2 {
+		currency = ctx.Args[2]
+	}
+
+	if err := projectService.UpdateProjectRate(ctx.Args[0], hourlyRate, currency); err != nil {
+		return err
+	}
+
+	renderer.Message("Project rate updated successfully")
+
+	return nil
+}
+
+// Parses the inclusive --from/--to days of a command into the range [from, to)
+func parseDayRange(ctx *cli.Context) (time.Time, time.Time, error) {
+	from, err := time.ParseInLocation(constants.DAY_FORMAT, ctx.String("from"), time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid --from date %s", ctx.String("from"))
+	}
+
+	to, err := time.ParseInLocation(constants.DAY_FORMAT, ctx.String("to"), time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid --to date %s", ctx.String("to"))
 	}
 
-	// Determine the task status based on the parsed flags
-	var statusFilter status.ItemStatus
-	switch {
-	case *markDone:
-		statusFilter = status.DONE
-	case *markInProgress:
-		statusFilter = status.IN_PROGRESS
-	case *markTodo:
-		statusFilter = status.TODO
+	return from, to.AddDate(0, 0, 1), nil
+}
+
+// Writes the output of an export to the file, or to the standard output if no file is given
+func writeExport(filePath string, renderer *render.Renderer, write func(w io.Writer) error) error {
+	if filePath == "" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("cannot create file: %w", err)
+	}
+	defer file.Close()
+
+	if err := write(file); err != nil {
+		return err
+	}
+
+	renderer.Messagef("Exported to %s", filePath)
+
+	return nil
+}
+
+func handleTimesheetCommand(ctx *cli.Context, billingService *billing.BillingService, renderer *render.Renderer) error {
+	from, to, err := parseDayRange(ctx)
+	if err != nil {
+		return err
+	}
+
+	rounding := billing.Rounding{IncrementMinutes: ctx.Int("round"), Mode: ctx.String("rounding")}
+	entries, err := billingService.BuildTimesheet(from, to, ctx.Int("project"), rounding)
+	if err != nil {
+		return err
+	}
+
+	return writeExport(ctx.String("file"), renderer, func(w io.Writer) error {
+		return billing.WriteTimesheetCSV(w, entries)
+	})
+}
+
+func handleInvoiceCommand(ctx *cli.Context, billingService *billing.BillingService, renderer *render.Renderer) error {
+	var writeInvoice func(w io.Writer, invoice *billing.Invoice) error
+	switch ctx.String("format") {
+	case billing.FORMAT_MARKDOWN:
+		writeInvoice = billing.WriteInvoiceMarkdown
+	case billing.FORMAT_HTML:
+		writeInvoice = billing.WriteInvoiceHTML
 	default:
-		fmt.Println("Invalid status. Use --done, --in-progress, or --todo.")
-		return
+		return ctx.UsageError("invalid format %s, expected md or html", ctx.String("format"))
+	}
+
+	from, to, err := parseDayRange(ctx)
+	if err != nil {
+		return err
+	}
+
+	rounding := billing.Rounding{IncrementMinutes: ctx.Int("round"), Mode: ctx.String("rounding")}
+	invoice, err := billingService.BuildInvoice(ctx.Int("project"), from, to, rounding)
+	if err != nil {
+		return err
+	}
+
+	return writeExport(ctx.String("file"), renderer, func(w io.Writer) error {
+		return writeInvoice(w, invoice)
+	})
+}
+
+func handleBurndownCommand(ctx *cli.Context, chartService *chart.ChartService, renderer *render.Renderer) error {
+	projectId, err := helpers.ValidateIdAndConvertToInt(ctx.Args[0])
+	if err != nil {
+		return err
+	}
+
+	burndown, err := chartService.BuildBurndown(projectId)
+	if err != nil {
+		return err
+	}
+
+	if err := renderer.Burndown(burndown, !ctx.Bool("ascii")); err != nil {
+		return err
 	}
 
-	if err := taskService.UpdateTaskStatus(taskID, statusFilter); err != nil {
-		fmt.Println("Error:", err)
+	if ctx.String("svg") == "" {
+		return nil
 	}
+
+	return writeExport(ctx.String("svg"), renderer, func(w io.Writer) error {
+		return chart.WriteLineSVG(w, burndown)
+	})
+}
+
+func handleBoardCommand(ctx *cli.Context, deps *commandDependencies) error {
+	project, err := deps.projectService.FindProjectByIdOrName(ctx.Args[0])
+	if err != nil {
+		return err
+	}
+
+	limits, err := board.ParseWIPLimits(ctx.String("wip"))
+	if err != nil {
+		return ctx.UsageError("%v", err)
+	}
+
+	tasks, err := deps.taskService.FindTasksOfProject(project.Id)
+	if err != nil {
+		return err
+	}
+
+	taskBoard, err := board.BuildBoard(fmt.Sprintf("Project %d: %s", project.Id, project.Name), tasks, deps.renderer.BoardConfig().Columns, deps.clock.Now())
+	if err != nil {
+		return err
+	}
+	taskBoard.SetWIPLimits(limits)
+
+	return deps.renderer.Board(taskBoard, ctx.Bool("details"))
+}
+
+func handleVelocityCommand(ctx *cli.Context, chartService *chart.ChartService, renderer *render.Renderer) error {
+	velocity, err := chartService.BuildVelocity(ctx.Int("weeks"), ctx.Int("project"))
+	if err != nil {
+		return err
+	}
+
+	if err := renderer.Velocity(velocity, !ctx.Bool("ascii")); err != nil {
+		return err
+	}
+
+	if ctx.String("svg") == "" {
+		return nil
+	}
+
+	return writeExport(ctx.String("svg"), renderer, func(w io.Writer) error {
+		return chart.WriteBarSVG(w, velocity)
+	})
+}
+
+func handleStatsCommand(ctx *cli.Context, statsService *stats.StatsService, renderer *render.Renderer, clock clock.Clock) error {
+	if ctx.Int("weeks") <= 0 {
+		return ctx.UsageError("number of weeks must be positive")
+	}
+
+	trackerStats, err := statsService.BuildStats()
+	if err != nil {
+		return err
+	}
+
+	return renderer.Stats(trackerStats, helpers.StartOfDay(clock.Now()), ctx.Int("weeks"), !ctx.Bool("ascii"))
 }
 
 func main() {
-	projectTable := tablewriter.NewWriter(os.Stdout)
-	projectService := project.NewProjectService(constants.PROJECT_FILE_NAME, projectTable)
+	systemClock := clock.NewRealClock()
 
-	taskTable := tablewriter.NewWriter(os.Stdout)
-	taskService := task.NewTaskService(projectService, taskTable)
+	searchIndex := search.NewIndex(constants.SEARCH_INDEX_FILE_NAME)
+	projectService := project.NewProjectService(constants.PROJECT_FILE_NAME, systemClock, searchIndex)
+	taskService := task.NewTaskService(projectService, systemClock, searchIndex)
 
-	circularDependencyManager := service.NewManager(taskService, projectService)
+	sessionService := session.NewSessionService(constants.SESSION_FILE_NAME, systemClock)
+	circularDependencyManager := service.NewManager(taskService, projectService, sessionService)
 
-	if len(os.Args) == 1 {
-		helpers.DisplayHelp()
-		return
+	goalService := goal.NewGoalService(constants.GOAL_FILE_NAME, sessionService, systemClock)
+
+	// The shell completion runs while the user types, so it must neither prompt nor fail on a half-typed --output
+	completing := len(os.Args) > 1 && os.Args[1] == cli.COMPLETE_COMMAND
+
+	timerService := timer.NewTimerService(constants.TIMER_FILE_NAME, taskService, circularDependencyManager, systemClock)
+	if !completing {
+		checkStaleTimer(timerService, systemClock)
 	}
 
-	switch os.Args[1] {
-	case constants.REPL:
-		handleREPLCommand(os.Args[2:], projectService, taskService, circularDependencyManager)
-	case constants.ADD:
-		handleProjectAddCommand(os.Args[2:], projectService)
-	case constants.LIST:
-		handleProjectListCommand(os.Args[2:], projectService)
-	case constants.UPDATE:
-		handleProjectUpdateCommand(os.Args[2:], projectService)
-	case constants.DELETE:
-		handleProjectDeleteCommand(os.Args[2:], circularDependencyManager)
-	case constants.MARK:
-		handleProjectMarkCommand(os.Args[2:], projectService)
-	case constants.HELP:
-		helpers.DisplayHelp()
-	default:
-		fmt.Println("Unknown command:", os.Args[1])
-		fmt.Println("Expected 'add', 'list', 'update', 'delete', 'mark', 'help' or 'repl' commands")
+	userConfig, err := config.LoadConfig(constants.CONFIG_FILE_NAME)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
-}
 
-func handleREPLCommand(args []string, projectService *project.ProjectService, taskService *task.TaskService, circularDependencyManager *service.Manager) {
-	if len(args) != 1 {
-		fmt.Println("USAGE: repl <project_id>")
-		return
+	configuredNotifier, err := notifier.NewNotifier(userConfig.Notifier, userConfig.NotifyCommand)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
 	}
 
-	projectId := args[0]
-	projectName := ""
-	if projectName = projectService.FindProjectNameById(projectId); projectName == "" {
-		fmt.Println("Project with ID=", projectId, "not found")
+	if err := render.ValidateTaskTable(userConfig.TaskTable); err != nil {
+		fmt.Fprintln(os.Stderr, "Error: invalid taskTable in", constants.CONFIG_FILE_NAME+":", err)
 		os.Exit(1)
 	}
 
-	startREPL(
-		circularDependencyManager,
-		projectId,
-		projectName,
-		taskService,
-	)
-}
+	if err := board.ValidateColumns(userConfig.Board.Columns, systemClock.Now()); err != nil {
+		fmt.Fprintln(os.Stderr, "Error: invalid board in", constants.CONFIG_FILE_NAME+":", err)
+		os.Exit(1)
+	}
 
-func handleProjectAddCommand(args []string, projectService *project.ProjectService) {
-	if len(args) < 1 {
-		fmt.Println("USAGE: add <project_name>")
-		return
+	renderer := render.NewRenderer(os.Stdout, output.TABLE)
+	renderer.SetTerminalWidth(helpers.TerminalWidth())
+	renderer.SetTaskTable(userConfig.TaskTable)
+	renderer.SetBoardConfig(userConfig.Board)
+
+	deps := &commandDependencies{
+		projectService:            projectService,
+		taskService:               taskService,
+		circularDependencyManager: circularDependencyManager,
+		searchIndex:               searchIndex,
+		goalService:               goalService,
+		reportService:             report.NewReportService(sessionService, projectService, taskService),
+		billingService:            billing.NewBillingService(sessionService, projectService, taskService, systemClock),
+		chartService:              chart.NewChartService(projectService, taskService, systemClock),
+		statsService:              stats.NewStatsService(projectService, taskService, sessionService, systemClock),
+		timers: &timerDependencies{
+			circularDependencyManager: circularDependencyManager,
+			timerService:              timerService,
+			notifier:                  configuredNotifier,
+			goalService:               goalService,
+			clock:                     systemClock,
+			idleAfterMinutes:          userConfig.IdleAfterMinutes,
+		},
+		renderer: renderer,
+		clock:    systemClock,
 	}
 
-	projectName := strings.Join(args, " ")
-	if err := projectService.CreateProject(projectName); err != nil {
-		fmt.Println("Error:", err)
+	// Exits with status 2 on invalid usage and 1 if the command fails
+	if err := newRegistry(deps).Run(os.Stdout, os.Args[1:]); err != nil {
+		printCommandError(err)
+		os.Exit(exitStatus(err))
 	}
 }
 
-func handleProjectListCommand(args []string, projectService *project.ProjectService) {
-	listCommand := flag.NewFlagSet(constants.LIST, flag.ExitOnError)
+func handleREPLCommand(ctx *cli.Context, deps *commandDependencies) error {
+	project, err := deps.projectService.FindProjectByIdOrName(ctx.Args[0])
+	if err != nil {
+		return err
+	}
 
-	listDone := listCommand.Bool("done", false, "List projects with status DONE")
-	listInProgress := listCommand.Bool("in-progress", false, "List projects with status IN_PROGRESS")
-	listTodo := listCommand.Bool("todo", false, "List projects with status TODO")
+	return startREPL(deps, project, ctx.Bool("stop-on-error"))
+}
 
-	listCommand.Parse(args)
+func handleTUICommand(ctx *cli.Context, deps *commandDependencies) error {
+	if !helpers.IsTerminal(os.Stdin) || !helpers.IsTerminal(os.Stdout) {
+		return errors.New("the terminal UI needs a terminal for its input and output")
+	}
 
-	var statusFilter status.ItemStatus
-	switch {
-	case *listDone:
-		statusFilter = status.DONE
-	case *listInProgress:
-		statusFilter = status.IN_PROGRESS
-	case *listTodo:
-		statusFilter = status.TODO
-	default:
-		statusFilter = -1
+	projectId := 0
+	if len(ctx.Args) == 1 {
+		project, err := deps.projectService.FindProjectByIdOrName(ctx.Args[0])
+		if err != nil {
+			return err
+		}
+		projectId = project.Id
 	}
 
-	if err := projectService.ListProjects(statusFilter); err != nil {
-		fmt.Println("Error:", err)
+	newCountdown := func(taskService *task.TaskService, taskId string) (*countdown.CountdownService, *task.Task, error) {
+		return newTaskCountdown(taskId, false, deps.timers.idleAfterMinutes, taskService, deps.timers)
 	}
+
+	return tui.NewApp(deps.projectService, deps.taskService, newCountdown, deps.renderer.BoardConfig().Columns, deps.clock, stdinReader, os.Stdout).Run(projectId)
 }
 
-func handleProjectUpdateCommand(args []string, projectService *project.ProjectService) {
-	if len(args) < 2 {
-		fmt.Println("USAGE: update <project_id> <new project name>")
-		return
+func handleProjectAddCommand(ctx *cli.Context, projectService *project.ProjectService, renderer *render.Renderer) error {
+	projectName := strings.Join(ctx.Args, " ")
+	if _, err := projectService.CreateProject(projectName); err != nil {
+		return err
 	}
 
-	projectName := strings.Join(args[1:], " ")
+	renderer.Message("Project created successfully")
 
-	if err := projectService.UpdateProjectName(args[0], projectName); err != nil {
-		fmt.Println("Error:", err)
+	return nil
+}
+
+func handleProjectListCommand(ctx *cli.Context, projectService *project.ProjectService, renderer *render.Renderer) error {
+	statusFilter, _ := statusFromFlags(ctx)
+
+	projects, err := projectService.FindProjectsByStatus(statusFilter)
+	if err != nil {
+		return err
 	}
+
+	return renderer.Projects(projects)
 }
 
-func handleProjectDeleteCommand(args []string, circularDependencyManager *service.Manager) {
-	deleteCommand := flag.NewFlagSet(constants.DELETE, flag.ExitOnError)
-	deleteAll := deleteCommand.Bool("all", false, "Delete all projects")
-	deleteCommand.Parse(args)
+func handleProjectUpdateCommand(ctx *cli.Context, projectService *project.ProjectService, renderer *render.Renderer) error {
+	projectName := strings.Join(ctx.Args[1:], " ")
 
-	if *deleteAll {
-		fmt.Println("Are you sure you want to delete all projects? This action will also delete all tasks. (y/n)")
-		reader := bufio.NewReader(os.Stdin)
+	if err := projectService.UpdateProjectName(ctx.Args[0], projectName); err != nil {
+		return err
+	}
+
+	renderer.Message("Project name updated successfully")
+
+	return nil
+}
+
+func handleProjectDescribeCommand(ctx *cli.Context, projectService *project.ProjectService, renderer *render.Renderer) error {
+	if err := projectService.UpdateProjectDescription(ctx.Args[0], strings.Join(ctx.Args[1:], " ")); err != nil {
+		return err
+	}
+
+	renderer.Message("Project description updated successfully")
+
+	return nil
+}
+
+func handleSearchCommand(ctx *cli.Context, taskService *task.TaskService, searchIndex *search.Index, renderer *render.Renderer) error {
+	results, err := searchIndex.Search(strings.Join(ctx.Args, " "), ctx.Int("limit"), taskService.SearchDocuments)
+	if err != nil {
+		return err
+	}
 
-		response, _ := reader.ReadString('\n')
+	return renderer.SearchResults(results)
+}
+
+func handleProjectDeleteCommand(ctx *cli.Context, circularDependencyManager *service.Manager, renderer *render.Renderer) error {
+	if ctx.Bool("all") {
+		fmt.Println("Are you sure you want to delete all projects? This action will also delete all tasks. (y/n)")
+		response, _ := stdinReader.ReadString('\n')
 		response = strings.TrimSpace(response)
 
 		if response != "y" {
 			fmt.Println("Command cancelled.")
-			return
+			return nil
 		}
 
 		if err := circularDependencyManager.DeleteAllProjectsWithAllTasks("", false); err != nil {
-			fmt.Println("Error:", err)
+			return err
 		}
 
-		return
+		renderer.Message("Projects deleted successfully")
+		return nil
 	}
 
-	if len(deleteCommand.Args()) != 1 {
-		fmt.Println("USAGE: delete <project_id> | --all")
-		return
+	if len(ctx.Args) != 1 {
+		return ctx.UsageError("expected a project ID or --all")
 	}
 
-	if err := circularDependencyManager.DeleteProjectAndCorrespondingTasks(deleteCommand.Args()[0]); err != nil {
-		fmt.Println("Error:", err)
+	if err := circularDependencyManager.DeleteProjectAndCorrespondingTasks(ctx.Args[0]); err != nil {
+		return err
 	}
+
+	renderer.Message("Project deleted successfully")
+
+	return nil
 }
 
-func handleProjectMarkCommand(args []string, projectService *project.ProjectService) {
-	if len(args) != 2 {
-		fmt.Println("USAGE: mark <project_id> --completed | --started | --not-started")
+func handleProjectMarkCommand(ctx *cli.Context, projectService *project.ProjectService, renderer *render.Renderer) error {
+	projectStatus, ok := statusFromFlags(ctx)
+	if !ok {
+		return ctx.UsageError("expected --done, --in-progress or --todo")
+	}
+
+	if err := projectService.UpdateProjectStatus(ctx.Args[0], projectStatus); err != nil {
+		return err
+	}
+
+	renderer.Message("Project status updated successfully")
+
+	return nil
+}
+
+// Detects a timer left running by a session that has exited (e.g. closed terminal or Ctrl-C) and offers to save it
+func checkStaleTimer(timerService *timer.TimerService, clock clock.Clock) {
+	activeTimer, err := timerService.Load()
+	if err != nil || activeTimer == nil || !activeTimer.IsStale() {
+		return
+	}
+
+	elapsedSeconds := activeTimer.ElapsedSeconds(clock.Now())
+	startedAt := activeTimer.StartedAt.Format(constants.DATE_FORMAT)
+	recorded := strings.TrimSpace(helpers.FormatSpendTime(elapsedSeconds))
+
+	// Piped input belongs to the command (e.g. a script), so the timer is kept on disk instead of prompting
+	if !helpers.IsTerminal(os.Stdin) || !helpers.IsTerminal(os.Stdout) {
+		fmt.Fprintf(os.Stderr, "Warning: a timer for the task \"%s\" was left running since %s (%s recorded). Run `%s %s` to save it.\n", activeTimer.TaskName, startedAt, recorded, constants.PERSISTENT_TIMER, constants.TIMER_STOP_COMMAND)
 		return
 	}
 
-	projectID := args[0]
-	// Create a flag set for parsing the status flags
-	markCommand := flag.NewFlagSet("mark", flag.ExitOnError)
+	fmt.Printf("A timer for the task \"%s\" was left running since %s (%s recorded).\n", activeTimer.TaskName, startedAt, recorded)
+	fmt.Println("Do you want to save the recorded time? (y/n)")
 
-	// Define the flags
-	markProjectDone := markCommand.Bool("done", false, "Mark project as DONE")
-	markProjectInProgress := markCommand.Bool("in-progress", false, "Mark project as IN_PROGRESS")
-	markProjectTodo := markCommand.Bool("todo", false, "Mark project as TODO")
+	response, _ := stdinReader.ReadString('\n')
+	response = strings.TrimSpace(response)
 
-	// Parse the remaining args after extracting the task ID
-	err := markCommand.Parse(args[1:])
-	if err != nil {
-		fmt.Println("Error parsing flags:", err)
+	if response != "y" {
+		if _, err := timerService.Discard(); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return
+		}
+		fmt.Println("Stale timer discarded.")
 		return
 	}
 
-	var statusFilter status.ItemStatus
-	switch {
-	case *markProjectDone:
-		statusFilter = status.DONE
-	case *markProjectInProgress:
-		statusFilter = status.IN_PROGRESS
-	case *markProjectTodo:
-		statusFilter = status.TODO
-	default:
-		fmt.Println("Invalid status. Use --completed, --started, or --not-started.")
+	if _, _, err := timerService.Stop(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		return
 	}
+	fmt.Println("Stale timer saved successfully")
+}
+
+func handleTimerStartCommand(ctx *cli.Context, taskService *task.TaskService, timerService *timer.TimerService, renderer *render.Renderer) error {
+	task, err := taskService.ForProject(strconv.Itoa(ctx.Int("project"))).FindTaskById(ctx.Args[0])
+	if err != nil {
+		return err
+	}
+
+	if _, err := timerService.Start(task, 0, 0); err != nil {
+		return err
+	}
+
+	renderer.Messagef("Timer started for the task \"%s\"", task.Name)
+
+	return nil
+}
+
+func handleTimerStopCommand(timers *timerDependencies, renderer *render.Renderer) error {
+	activeTimer, elapsedSeconds, err := timers.timerService.Stop()
+	if err != nil {
+		return err
+	}
+	renderer.Messagef("Timer stopped, %s saved to the task \"%s\"", strings.TrimSpace(helpers.FormatSpendTime(elapsedSeconds)), activeTimer.TaskName)
+
+	goalProgress, err := timers.goalService.FindProjectGoalProgress(activeTimer.ProjectId)
+	if err != nil {
+		return err
+	}
+
+	for _, reachedGoal := range timers.goalService.CollectReachedGoals(goalProgress, 0) {
+		message := fmt.Sprintf("You have reached your goal of %s", goal.DescribeGoal(reachedGoal))
+		renderer.Message(message)
+		timers.notifier.Notify("Goal reached", message)
+	}
 
-	if err := projectService.UpdateProjectStatus(projectID, statusFilter); err != nil {
-		fmt.Println("Error:", err)
+	return nil
+}
+
+func handleGoalSetCommand(ctx *cli.Context, goalService *goal.GoalService, renderer *render.Renderer) error {
+	target, err := time.ParseDuration(ctx.Args[1])
+	if err != nil {
+		return fmt.Errorf("invalid duration %s", ctx.Args[1])
+	}
+
+	replaced, err := goalService.SetGoal(ctx.Args[0], int(target.Seconds()), ctx.Int("project"))
+	if err != nil {
+		return err
 	}
+
+	if replaced {
+		renderer.Message("Goal updated successfully")
+	} else {
+		renderer.Message("Goal created successfully")
+	}
+
+	return nil
+}
+
+func handleReportCommand(ctx *cli.Context, reportService *report.ReportService, renderer *render.Renderer) error {
+	from, to, err := parseDayRange(ctx)
+	if err != nil {
+		return err
+	}
+
+	focusReport, err := reportService.BuildReport(from, to, ctx.String("group-by"), ctx.Int("project"))
+	if err != nil {
+		return err
+	}
+
+	return renderer.Report(focusReport)
 }
@@ -0,0 +1,91 @@
+package notifier
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+)
+
+// Notifier informs the user about timer events (completion, early stop, break transitions)
+type Notifier interface {
+	Notify(title, message string) error
+}
+
+// NOTIFIER KINDS:
+const (
+	BELL    = "bell"
+	DESKTOP = "desktop"
+	COMMAND = "command"
+	SILENT  = "silent"
+)
+
+// Creates the notifier of the given kind. The command is only used by the COMMAND notifier
+func NewNotifier(kind string, command string) (Notifier, error) {
+	switch kind {
+	case "", BELL:
+		return &BellNotifier{}, nil
+	case DESKTOP:
+		return &DesktopNotifier{}, nil
+	case COMMAND:
+		if command == "" {
+			return nil, fmt.Errorf("notifier %q requires a command", COMMAND)
+		}
+		return &CommandNotifier{Command: command}, nil
+	case SILENT:
+		return &SilentNotifier{}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q, expected %q, %q, %q or %q", kind, BELL, DESKTOP, COMMAND, SILENT)
+	}
+}
+
+// BellNotifier rings the terminal bell
+type BellNotifier struct{}
+
+func (n *BellNotifier) Notify(title, message string) error {
+	_, err := fmt.Fprint(os.Stdout, "\a")
+	return err
+}
+
+// DesktopNotifier shows a desktop notification (notify-send or D-Bus on Linux, osascript on macOS)
+type DesktopNotifier struct{}
+
+func (n *DesktopNotifier) Notify(title, message string) error {
+	if runtime.GOOS == "darwin" {
+		script := fmt.Sprintf("display notification %s with title %s", strconv.Quote(message), strconv.Quote(title))
+		return exec.Command("osascript", "-e", script).Run()
+	}
+
+	if _, err := exec.LookPath("notify-send"); err == nil {
+		return exec.Command("notify-send", title, message).Run()
+	}
+
+	// Fall back to calling the notification service over D-Bus directly
+	return exec.Command("gdbus", "call", "--session",
+		"--dest", "org.freedesktop.Notifications",
+		"--object-path", "/org/freedesktop/Notifications",
+		"--method", "org.freedesktop.Notifications.Notify",
+		"todo-tracker", "0", "", title, message, "[]", "{}", "5000",
+	).Run()
+}
+
+// CommandNotifier runs a user-configured shell command. The title and message
+// are passed in the TODO_TRACKER_TITLE and TODO_TRACKER_MESSAGE environment variables
+type CommandNotifier struct {
+	Command string
+}
+
+func (n *CommandNotifier) Notify(title, message string) error {
+	cmd := exec.Command("sh", "-c", n.Command)
+	cmd.Env = append(os.Environ(), "TODO_TRACKER_TITLE="+title, "TODO_TRACKER_MESSAGE="+message)
+
+	return cmd.Run()
+}
+
+// SilentNotifier ignores all timer events
+type SilentNotifier struct{}
+
+func (n *SilentNotifier) Notify(title, message string) error {
+	return nil
+}
@@ -0,0 +1,193 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Format is the format of the output of the list, show and report commands
+type Format string
+
+// OUTPUT FORMATS:
+const (
+	TABLE    Format = "table"
+	JSON     Format = "json"
+	CSV      Format = "csv"
+	TSV      Format = "tsv"
+	PLAIN    Format = "plain"
+	MARKDOWN Format = "markdown"
+)
+
+// Parses the value of the --output option
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(value)); format {
+	case TABLE, JSON, CSV, TSV, PLAIN, MARKDOWN:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid output format %q, expected table, json, csv, tsv, plain or markdown", value)
+	}
+}
+
+// Checks if the output is the bordered table meant for humans (the empty format is the default table)
+func (f Format) IsTable() bool {
+	return f == "" || f == TABLE
+}
+
+// Writes the value as indented JSON. Times are written in RFC 3339
+func WriteJSON(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(value)
+}
+
+// Writes the records (a slice of structs) in the format. The columns are the JSON names of the fields,
+// times are written in RFC 3339 and durations as raw seconds, so the output is stable for scripts
+func WriteRecords(w io.Writer, format Format, records any) error {
+	return WriteRecordFields(w, format, records, nil)
+}
+
+// Writes the records like WriteRecords, keeping only the fields with the given JSON names in their order
+// (all fields if none are given). JSON is written with all fields so the objects keep their shape
+func WriteRecordFields(w io.Writer, format Format, records any, fields []string) error {
+	if format == JSON {
+		return WriteJSON(w, records)
+	}
+
+	header, rows, err := flatten(records, fields)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case CSV, TSV:
+		writer := csv.NewWriter(w)
+		if format == TSV {
+			writer.Comma = '\t'
+		}
+
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			return err
+		}
+
+		return writer.Error()
+	case MARKDOWN:
+		fmt.Fprintf(w, "| %s |\n", strings.Join(header, " | "))
+		fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(header)))
+		for _, row := range rows {
+			for i := range row {
+				row[i] = strings.ReplaceAll(row[i], "|", "\\|")
+			}
+			fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | "))
+		}
+
+		return nil
+	case PLAIN:
+		writer := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.Join(header, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(writer, strings.Join(row, "\t"))
+		}
+
+		return writer.Flush()
+	default:
+		return fmt.Errorf("records cannot be written as %q", format)
+	}
+}
+
+// Converts a slice of structs into a header (the JSON names of the fields) and rows of values,
+// keeping only the given fields if any are given
+func flatten(records any, fields []string) ([]string, [][]string, error) {
+	value := reflect.ValueOf(records)
+	if value.Kind() != reflect.Slice {
+		return nil, nil, fmt.Errorf("records must be a slice, got %s", value.Kind())
+	}
+
+	recordType := value.Type().Elem()
+	if recordType.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("records must be structs, got %s", recordType.Kind())
+	}
+
+	header := []string{}
+	fieldIndexes := []int{}
+	for i := 0; i < recordType.NumField(); i++ {
+		field := recordType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		header = append(header, name)
+		fieldIndexes = append(fieldIndexes, i)
+	}
+
+	if len(fields) > 0 {
+		selectedHeader := []string{}
+		selectedIndexes := []int{}
+		for _, field := range fields {
+			position := slices.Index(header, field)
+			if position == -1 {
+				return nil, nil, fmt.Errorf("unknown field %q of the records", field)
+			}
+			selectedHeader = append(selectedHeader, field)
+			selectedIndexes = append(selectedIndexes, fieldIndexes[position])
+		}
+		header, fieldIndexes = selectedHeader, selectedIndexes
+	}
+
+	rows := [][]string{}
+	for i := 0; i < value.Len(); i++ {
+		row := []string{}
+		for _, fieldIndex := range fieldIndexes {
+			row = append(row, formatValue(value.Index(i).Field(fieldIndex)))
+		}
+		rows = append(rows, row)
+	}
+
+	return header, rows, nil
+}
+
+func formatValue(value reflect.Value) string {
+	if t, ok := value.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	if stringer, ok := value.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			return ""
+		}
+		return formatValue(value.Elem())
+	case reflect.Slice:
+		values := []string{}
+		for i := 0; i < value.Len(); i++ {
+			values = append(values, formatValue(value.Index(i)))
+		}
+		return strings.Join(values, ";")
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64)
+	default:
+		return fmt.Sprint(value.Interface())
+	}
+}
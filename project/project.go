@@ -3,37 +3,79 @@ package project
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/MuradIsayev/todo-tracker/base"
-	"github.com/MuradIsayev/todo-tracker/constants"
+	"github.com/MuradIsayev/todo-tracker/clock"
 	"github.com/MuradIsayev/todo-tracker/helpers"
+	"github.com/MuradIsayev/todo-tracker/search"
 	"github.com/MuradIsayev/todo-tracker/status"
-	"github.com/olekukonko/tablewriter"
 )
 
 type Project struct {
 	Id             int               `json:"id"`
 	Name           string            `json:"name"`
+	Description    string            `json:"description,omitempty"`
 	Status         status.ItemStatus `json:"status"`
 	CreatedAt      time.Time         `json:"createdAt"`
 	UpdatedAt      time.Time         `json:"updatedAt"`
 	TotalSpentTime int               `json:"totalSpentTime"`
 	NbOfTotalTasks int               `json:"nbOfTotalTasks"`
+	HourlyRate     float64           `json:"hourlyRate,omitempty"`
+	Currency       string            `json:"currency,omitempty"`
+}
+
+// ProjectRecord is the machine-readable representation of a project
+type ProjectRecord struct {
+	Id                int       `json:"id"`
+	Name              string    `json:"name"`
+	Description       string    `json:"description"`
+	Status            string    `json:"status"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+	TotalSpentSeconds int       `json:"totalSpentSeconds"`
+	TotalTasks        int       `json:"totalTasks"`
+	HourlyRate        float64   `json:"hourlyRate"`
+	Currency          string    `json:"currency"`
+}
+
+func NewProjectRecord(project Project) ProjectRecord {
+	return ProjectRecord{
+		Id:                project.Id,
+		Name:              project.Name,
+		Description:       project.Description,
+		Status:            project.Status.String(),
+		CreatedAt:         project.CreatedAt,
+		UpdatedAt:         project.UpdatedAt,
+		TotalSpentSeconds: project.TotalSpentTime,
+		TotalTasks:        project.NbOfTotalTasks,
+		HourlyRate:        project.HourlyRate,
+		Currency:          project.Currency,
+	}
+}
+
+// Returns the searchable text of the project
+func (p Project) SearchDocument() search.Document {
+	document := search.NewDocument(search.KIND_PROJECT, p.Id, p.Id, p.Name)
+	document.AddText(p.Name, 3)
+	document.AddText(p.Description, 2)
+
+	return *document
 }
 
 type ProjectService struct {
 	baseService *base.BaseService[Project]
-	table       *tablewriter.Table
+	clock       clock.Clock
 }
 
-func NewProjectService(filePath string, table *tablewriter.Table) *ProjectService {
-	table.SetHeader([]string{constants.COLUMN_ID, constants.COLUMN_NAME, constants.COLUMN_STATUS, constants.COLUMN_CREATE_DATE, constants.COLUMN_UPDATE_DATE, constants.COLUMN_TOTAL_SPENT_TIME, constants.COLUMN_TOTAL_TASKS, ""})
-
+func NewProjectService(filePath string, clock clock.Clock, searchIndex *search.Index) *ProjectService {
 	return &ProjectService{
-		table: table,
+		clock: clock,
 		baseService: &base.BaseService[Project]{
 			FilePath: filePath,
+			Clock:    clock,
+			Index:    searchIndex,
 		},
 	}
 }
@@ -45,23 +87,11 @@ type ProjectManager interface {
 }
 
 func (p *ProjectService) DeleteAllProjects() error {
-	if err := p.baseService.DeleteAllItems(); err != nil {
-		return err
-	}
-
-	fmt.Println("Projects deleted successfully")
-
-	return nil
+	return p.baseService.DeleteAllItems()
 }
 
 func (p *ProjectService) DeleteProjectById(projectId string) error {
-	if err := p.baseService.DeleteItemById(projectId); err != nil {
-		return err
-	}
-
-	fmt.Println("Project deleted successfully")
-
-	return nil
+	return p.baseService.DeleteItemById(projectId)
 }
 
 func (p *ProjectService) UpdateProjectTimer(projectId int, newDuration int) error {
@@ -69,49 +99,70 @@ func (p *ProjectService) UpdateProjectTimer(projectId int, newDuration int) erro
 }
 
 func (s *ProjectService) UpdateProjectStatus(id string, projectStatus status.ItemStatus) error {
-	if err := s.baseService.UpdateItemStatus(id, projectStatus); err != nil {
-		return err
-	}
+	return s.baseService.UpdateItemStatus(id, projectStatus)
+}
 
-	fmt.Println("Project status updated successfully")
+func (s *ProjectService) UpdateProjectName(id, name string) error {
+	return s.baseService.UpdateItemName(id, name)
+}
 
-	return nil
+func (s *ProjectService) UpdateProjectDescription(id, description string) error {
+	return s.baseService.UpdateItemDescription(id, description)
 }
 
-func (s *ProjectService) UpdateProjectName(id, name string) error {
-	if err := s.baseService.UpdateItemName(id, name); err != nil {
+// Sets the hourly rate (and optionally the currency) used to bill the focused time of the project
+func (s *ProjectService) UpdateProjectRate(id string, hourlyRate float64, currency string) error {
+	if hourlyRate < 0 {
+		return fmt.Errorf("hourly rate cannot be negative")
+	}
+
+	projectId, err := helpers.ValidateIdAndConvertToInt(id)
+	if err != nil {
 		return err
 	}
 
-	fmt.Println("Project name updated successfully")
+	projects := []Project{}
+	if err := s.baseService.ReadFromFile(&projects); err != nil {
+		return err
+	}
 
-	return nil
+	index, project, err := s.baseService.FindItemById(projects, projectId)
+	if err != nil {
+		return err
+	}
+
+	project.HourlyRate = hourlyRate
+	if currency != "" {
+		project.Currency = strings.ToUpper(currency)
+	}
+	project.UpdatedAt = s.clock.Now()
+	projects[index] = *project
+
+	return s.baseService.WriteToFile(projects)
 }
 
-func (s *ProjectService) CreateProject(name string) error {
+func (s *ProjectService) CreateProject(name string) (*Project, error) {
 	projects := []Project{}
 	err := s.baseService.ReadFromFile(&projects)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	project := Project{
 		Id:        s.baseService.GetNextID(projects),
 		Name:      name,
 		Status:    status.TODO,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: s.clock.Now(),
+		UpdatedAt: s.clock.Now(),
 	}
 
 	projects = append(projects, project)
 
 	if err := s.baseService.WriteToFile(projects); err != nil {
-		return err
+		return nil, err
 	}
 
-	fmt.Println("Project created successfully")
-
-	return nil
+	return &project, nil
 }
 
 func (s *ProjectService) UpdateTotalTasksOfProject(id string, nbOfTotalTasks int) error {
@@ -134,11 +185,62 @@ func (s *ProjectService) UpdateTotalTasksOfProject(id string, nbOfTotalTasks int
 	project.NbOfTotalTasks = nbOfTotalTasks
 	projects[index] = *project
 
-	if err := s.baseService.WriteToFile(projects); err != nil {
-		return err
+	return s.baseService.WriteToFile(projects)
+}
+
+func (s *ProjectService) FindProjects() ([]Project, error) {
+	projects := []Project{}
+	if err := s.baseService.ReadFromFile(&projects); err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+// Returns the path of the projects file and the search documents of the projects
+func (s *ProjectService) SearchDocuments() (string, []search.Document, error) {
+	projects, err := s.FindProjects()
+	if err != nil {
+		return "", nil, err
 	}
 
-	return nil
+	return s.baseService.FilePath, s.baseService.SearchDocuments(projects), nil
+}
+
+// Finds the project by its ID or, case-insensitively, by its name
+func (s *ProjectService) FindProjectByIdOrName(value string) (*Project, error) {
+	projects, err := s.FindProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	if projectId, err := helpers.ValidateIdAndConvertToInt(value); err == nil {
+		_, project, err := s.baseService.FindItemById(projects, projectId)
+		if err != nil {
+			return nil, fmt.Errorf("project with ID=%d not found", projectId)
+		}
+		return project, nil
+	}
+
+	matchingProjects := []Project{}
+	for _, project := range projects {
+		if strings.EqualFold(project.Name, value) {
+			matchingProjects = append(matchingProjects, project)
+		}
+	}
+
+	switch len(matchingProjects) {
+	case 0:
+		return nil, fmt.Errorf("project %q not found", value)
+	case 1:
+		return &matchingProjects[0], nil
+	default:
+		ids := []string{}
+		for _, project := range matchingProjects {
+			ids = append(ids, strconv.Itoa(project.Id))
+		}
+		return nil, fmt.Errorf("several projects are named %q (IDs %s), use the project ID instead", value, strings.Join(ids, ", "))
+	}
 }
 
 func (s *ProjectService) FindProjectNameById(id string) string {
@@ -162,57 +264,22 @@ func (s *ProjectService) FindProjectNameById(id string) string {
 }
 
 func (s *ProjectService) UpdateProjectSpentTime(id int, spentTime int) error {
-	if err := s.baseService.UpdateTotalSpentTime(id, spentTime); err != nil {
-		return err
-	}
-
-	return nil
+	return s.baseService.UpdateTotalSpentTime(id, spentTime)
 }
 
-func defineFooterText(nbOfLeftProjects, nbOfTotalProjects int) string {
-	if nbOfLeftProjects == 0 && nbOfTotalProjects == 0 {
-		return "No projects found"
-	}
-
-	return fmt.Sprintf("Left projects: %d", nbOfLeftProjects)
-}
-
-func (s *ProjectService) ListProjects(statusFilter status.ItemStatus) error {
-	projects := []Project{}
-	err := s.baseService.ReadFromFile(&projects)
+// Finds the projects with the status. A statusFilter of -1 matches all statuses
+func (s *ProjectService) FindProjectsByStatus(statusFilter status.ItemStatus) ([]Project, error) {
+	projects, err := s.FindProjects()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var nbOfLeftprojects int
-
+	filteredProjects := []Project{}
 	for _, project := range projects {
 		if statusFilter == -1 || project.Status == statusFilter {
-			createdAt := project.CreatedAt.Format(constants.DATE_FORMAT)
-			updatedAt := project.UpdatedAt.Format(constants.DATE_FORMAT)
-			totalSpentTime := helpers.FormatSpendTime(project.TotalSpentTime)
-
-			s.table.Append([]string{strconv.Itoa(project.Id), project.Name, project.Status.String(), createdAt, updatedAt, totalSpentTime, strconv.Itoa(project.NbOfTotalTasks), ""})
-			if project.Status == status.TODO {
-				nbOfLeftprojects++
-			}
+			filteredProjects = append(filteredProjects, project)
 		}
 	}
 
-	s.table.SetRowLine(true)
-	s.table.SetFooter([]string{"", "", "", "", "", "", " ", defineFooterText(nbOfLeftprojects, len(projects))})
-	s.table.SetHeaderColor(tablewriter.Colors{tablewriter.Bold},
-		tablewriter.Colors{tablewriter.Bold},
-		tablewriter.Colors{tablewriter.Bold},
-		tablewriter.Colors{tablewriter.Bold},
-		tablewriter.Colors{tablewriter.Bold},
-		tablewriter.Colors{tablewriter.Bold},
-		tablewriter.Colors{tablewriter.Bold},
-		tablewriter.Colors{tablewriter.Bold},
-	)
-	s.table.SetFooterColor(tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold})
-
-	s.table.Render()
-
-	return nil
+	return filteredProjects, nil
 }
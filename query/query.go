@@ -0,0 +1,350 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MuradIsayev/todo-tracker/constants"
+	"github.com/MuradIsayev/todo-tracker/helpers"
+	"github.com/MuradIsayev/todo-tracker/status"
+	"github.com/MuradIsayev/todo-tracker/task"
+)
+
+// QUERY OPERATORS:
+const (
+	EQUALS        = ":"
+	CONTAINS      = "~"
+	LESS          = "<"
+	LESS_EQUAL    = "<="
+	GREATER       = ">"
+	GREATER_EQUAL = ">="
+)
+
+// Query is a list of conditions that a task must all match, e.g. `status:todo tag:backend due<7d spent>2h name~"auth" project:3`
+type Query struct {
+	conditions []condition
+}
+
+// condition checks a single term of the query against a task
+type condition func(t task.Task) bool
+
+// Durations such as "7d", "2h", "1h30m" or "-2w"
+var durationRegex = regexp.MustCompile(`^-?(\d+[wdhms])+$`)
+var durationPartRegex = regexp.MustCompile(`(\d+)([wdhms])`)
+
+var durationUnits = map[string]time.Duration{
+	"w": 7 * 24 * time.Hour,
+	"d": 24 * time.Hour,
+	"h": time.Hour,
+	"m": time.Minute,
+	"s": time.Second,
+}
+
+// Parses the query. Relative dates such as due<7d are resolved against now
+func Parse(input string, now time.Time) (*Query, error) {
+	terms, err := splitTerms(input)
+	if err != nil {
+		return nil, err
+	}
+
+	query := &Query{}
+	for _, term := range terms {
+		condition, err := parseCondition(term, now)
+		if err != nil {
+			return nil, err
+		}
+
+		query.conditions = append(query.conditions, condition)
+	}
+
+	return query, nil
+}
+
+// Checks if the task matches all conditions of the query
+func (q *Query) Match(t task.Task) bool {
+	for _, condition := range q.conditions {
+		if !condition(t) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Splits the query into terms at whitespace, keeping quoted values such as name~"auth flow" together
+func splitTerms(input string) ([]string, error) {
+	terms := []string{}
+	var term strings.Builder
+	inQuotes := false
+	hasTerm := false
+
+	for _, r := range input {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			hasTerm = true
+		case !inQuotes && (r == ' ' || r == '\t'):
+			if hasTerm {
+				terms = append(terms, term.String())
+				term.Reset()
+				hasTerm = false
+			}
+		default:
+			term.WriteRune(r)
+			hasTerm = true
+		}
+	}
+
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote in query")
+	}
+
+	if hasTerm {
+		terms = append(terms, term.String())
+	}
+
+	return terms, nil
+}
+
+// Parses a term such as "spent>2h". A term without an operator matches the task name
+func parseCondition(term string, now time.Time) (condition, error) {
+	index := strings.IndexAny(term, ":~<>")
+	if index <= 0 {
+		return nameCondition(CONTAINS, term), nil
+	}
+
+	field := strings.ToLower(term[:index])
+	operator := term[index : index+1]
+	if (operator == LESS || operator == GREATER) && strings.HasPrefix(term[index+1:], "=") {
+		operator += "="
+	}
+	value := term[index+len(operator):]
+
+	if value == "" {
+		return nil, fmt.Errorf("missing value in %q", term)
+	}
+
+	switch field {
+	case "name":
+		if operator != EQUALS && operator != CONTAINS {
+			return nil, invalidOperatorError(field, operator)
+		}
+		return nameCondition(operator, value), nil
+	case "status":
+		return statusCondition(operator, value)
+	case "tag":
+		return tagCondition(operator, value)
+	case "project":
+		return projectCondition(operator, value)
+	case "spent":
+		return spentCondition(operator, value)
+	case "due", "created", "updated":
+		return dateCondition(field, operator, value, now)
+	case "older":
+		return olderCondition(operator, value, now)
+	default:
+		return nil, fmt.Errorf("unknown field %q, expected name, status, tag, project, spent, due, created, updated or older", field)
+	}
+}
+
+func invalidOperatorError(field, operator string) error {
+	return fmt.Errorf("operator %q is not supported by %q", operator, field)
+}
+
+func nameCondition(operator, value string) condition {
+	value = strings.ToLower(value)
+
+	return func(t task.Task) bool {
+		if operator == EQUALS {
+			return strings.ToLower(t.Name) == value
+		}
+		return strings.Contains(strings.ToLower(t.Name), value)
+	}
+}
+
+func statusCondition(operator, value string) (condition, error) {
+	if operator != EQUALS {
+		return nil, invalidOperatorError("status", operator)
+	}
+
+	itemStatus, err := status.ParseStatus(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(t task.Task) bool {
+		return t.Status == itemStatus
+	}, nil
+}
+
+func tagCondition(operator, value string) (condition, error) {
+	if operator != EQUALS && operator != CONTAINS {
+		return nil, invalidOperatorError("tag", operator)
+	}
+
+	value = strings.ToLower(value)
+
+	return func(t task.Task) bool {
+		if operator == EQUALS {
+			return slices.Contains(t.Tags, value)
+		}
+		return slices.ContainsFunc(t.Tags, func(tag string) bool { return strings.Contains(tag, value) })
+	}, nil
+}
+
+func projectCondition(operator, value string) (condition, error) {
+	if operator != EQUALS {
+		return nil, invalidOperatorError("project", operator)
+	}
+
+	projectId, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid project ID %q", value)
+	}
+
+	return func(t task.Task) bool {
+		return t.ProjectId == projectId
+	}, nil
+}
+
+func spentCondition(operator, value string) (condition, error) {
+	if operator == CONTAINS {
+		return nil, invalidOperatorError("spent", operator)
+	}
+
+	spent, err := ParseDuration(value)
+	if err != nil {
+		return nil, err
+	}
+
+	spentSeconds := int(spent.Seconds())
+
+	return func(t task.Task) bool {
+		return compare(t.TotalSpentTime-spentSeconds, operator)
+	}, nil
+}
+
+// Compares the dates of the field to a day (YYYY-MM-DD) or to a time relative to now (e.g. 7d or -2w).
+// "due:2026-10-25" and "due:1d" match the whole day, tasks without a due date match no date condition
+func dateCondition(field, operator, value string, now time.Time) (condition, error) {
+	if operator == CONTAINS {
+		return nil, invalidOperatorError(field, operator)
+	}
+
+	from, to, err := parseDateValue(value, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// A relative time compared for equality matches its whole day
+	if operator == EQUALS && from.Equal(to) {
+		from = helpers.StartOfDay(from)
+		to = from.AddDate(0, 0, 1)
+	}
+
+	return func(t task.Task) bool {
+		var date time.Time
+		switch field {
+		case "due":
+			if t.DueDate != nil {
+				date = *t.DueDate
+			}
+		case "created":
+			date = t.CreatedAt
+		case "updated":
+			date = t.UpdatedAt
+		}
+
+		if date.IsZero() {
+			return false
+		}
+
+		switch operator {
+		case EQUALS:
+			return !date.Before(from) && date.Before(to)
+		case LESS:
+			return date.Before(from)
+		case LESS_EQUAL:
+			return date.Before(to)
+		case GREATER:
+			return !date.Before(to)
+		default:
+			return !date.Before(from)
+		}
+	}, nil
+}
+
+// Matches the tasks last updated longer ago than the duration, e.g. "older:30d"
+func olderCondition(operator, value string, now time.Time) (condition, error) {
+	if operator != EQUALS {
+		return nil, invalidOperatorError("older", operator)
+	}
+
+	age, err := ParseDuration(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(t task.Task) bool {
+		return t.UpdatedAt.Before(now.Add(-age))
+	}, nil
+}
+
+// Returns the range [from, to) the date value stands for: a whole day, or an instant for a relative time
+func parseDateValue(value string, now time.Time) (time.Time, time.Time, error) {
+	if day, err := time.ParseInLocation(constants.DAY_FORMAT, value, time.Local); err == nil {
+		return day, day.AddDate(0, 0, 1), nil
+	}
+
+	offset, err := ParseDuration(value)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or a relative time such as 7d", value)
+	}
+
+	at := now.Add(offset)
+
+	return at, at, nil
+}
+
+// Parses a duration with the units w, d, h, m and s, e.g. "2h", "1h30m" or "-7d"
+func ParseDuration(value string) (time.Duration, error) {
+	if !durationRegex.MatchString(value) {
+		return 0, fmt.Errorf("invalid duration %q, expected e.g. 2h, 30m or 7d", value)
+	}
+
+	var duration time.Duration
+	for _, part := range durationPartRegex.FindAllStringSubmatch(value, -1) {
+		amount, err := strconv.Atoi(part[1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+
+		duration += time.Duration(amount) * durationUnits[part[2]]
+	}
+
+	if strings.HasPrefix(value, "-") {
+		duration = -duration
+	}
+
+	return duration, nil
+}
+
+// Applies the comparison operator to the difference of two values
+func compare(difference int, operator string) bool {
+	switch operator {
+	case LESS:
+		return difference < 0
+	case LESS_EQUAL:
+		return difference <= 0
+	case GREATER:
+		return difference > 0
+	case GREATER_EQUAL:
+		return difference >= 0
+	default:
+		return difference == 0
+	}
+}
@@ -0,0 +1,167 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MuradIsayev/todo-tracker/status"
+	"github.com/MuradIsayev/todo-tracker/task"
+)
+
+var now = time.Date(2026, 10, 19, 12, 0, 0, 0, time.Local)
+
+// Returns a task updated 10 days ago, due in 3 days, with 2h30m spent
+func newTestTask() task.Task {
+	dueDate := time.Date(2026, 10, 22, 0, 0, 0, 0, time.Local)
+
+	return task.Task{
+		Id:             3,
+		Name:           "Fix auth flow",
+		Status:         status.IN_PROGRESS,
+		CreatedAt:      now.AddDate(0, 0, -20),
+		UpdatedAt:      now.AddDate(0, 0, -10),
+		TotalSpentTime: int((2*time.Hour + 30*time.Minute).Seconds()),
+		ProjectId:      1,
+		Tags:           []string{"backend", "security"},
+		DueDate:        &dueDate,
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for _, test := range []struct {
+		query string
+		want  bool
+	}{
+		{"", true},
+		{"auth", true},
+		{"AUTH", true},
+		{"login", false},
+		{`name:"fix auth flow"`, true},
+		{"name:fix", false},
+		{"name~fix", true},
+		{`name~"auth flow"`, true},
+		{"status:in-progress", true},
+		{"status:todo", false},
+		{"tag:backend", true},
+		{"tag:back", false},
+		{"tag~back", true},
+		{"project:1", true},
+		{"project:2", false},
+		{"status:in-progress tag:backend project:1", true},
+		{"status:in-progress tag:frontend", false},
+	} {
+		query, err := Parse(test.query, now)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", test.query, err)
+			continue
+		}
+
+		if got := query.Match(newTestTask()); got != test.want {
+			t.Errorf("Parse(%q).Match = %v, want %v", test.query, got, test.want)
+		}
+	}
+}
+
+func TestMatchDurationsAndDates(t *testing.T) {
+	for _, test := range []struct {
+		query string
+		want  bool
+	}{
+		{"spent>2h", true},
+		{"spent>=2h30m", true},
+		{"spent:2h30m", true},
+		{"spent<2h30m", false},
+		{"spent<=3h", true},
+		{"due<7d", true},
+		{"due<2d", false},
+		{"due:3d", true},
+		{"due:2026-10-22", true},
+		{"due>2026-10-21", true},
+		{"due>=2026-10-22", true},
+		{"due<=2026-10-22", true},
+		{"due<2026-10-22", false},
+		{"due>2026-10-22", false},
+		{"created<-2w", true},
+		{"updated>-1w", false},
+		{"older:7d", true},
+		{"older:30d", false},
+	} {
+		query, err := Parse(test.query, now)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", test.query, err)
+			continue
+		}
+
+		if got := query.Match(newTestTask()); got != test.want {
+			t.Errorf("Parse(%q).Match = %v, want %v", test.query, got, test.want)
+		}
+	}
+}
+
+func TestDateConditionsSkipTasksWithoutDueDate(t *testing.T) {
+	noDueDate := newTestTask()
+	noDueDate.DueDate = nil
+
+	for _, input := range []string{"due<7d", "due>-7d", "due:2026-10-22"} {
+		query, err := Parse(input, now)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", input, err)
+		}
+
+		if query.Match(noDueDate) {
+			t.Errorf("Parse(%q).Match = true for a task without due date, want false", input)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		`name~"auth`,
+		"status:",
+		"color:red",
+		"status:blocked",
+		"status~todo",
+		"name<auth",
+		"project:abc",
+		"project>1",
+		"spent>2x",
+		"spent~2h",
+		"due<tomorrow",
+		"due~2026-10-22",
+		"older>30d",
+		"older:30",
+	} {
+		if _, err := Parse(input, now); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for _, test := range []struct {
+		value string
+		want  time.Duration
+	}{
+		{"2h", 2 * time.Hour},
+		{"1h30m", time.Hour + 30*time.Minute},
+		{"7d", 7 * 24 * time.Hour},
+		{"-2w", -14 * 24 * time.Hour},
+		{"45s", 45 * time.Second},
+	} {
+		got, err := ParseDuration(test.value)
+		if err != nil {
+			t.Errorf("ParseDuration(%q): %v", test.value, err)
+			continue
+		}
+
+		if got != test.want {
+			t.Errorf("ParseDuration(%q) = %v, want %v", test.value, got, test.want)
+		}
+	}
+
+	for _, value := range []string{"", "2", "h", "2y", "1.5h", "+2h"} {
+		if _, err := ParseDuration(value); err == nil {
+			t.Errorf("ParseDuration(%q) succeeded, want an error", value)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MuradIsayev/todo-tracker/board"
+	"github.com/MuradIsayev/todo-tracker/constants"
+	"github.com/MuradIsayev/todo-tracker/output"
+	"github.com/mattn/go-runewidth"
+)
+
+// Width of the board when the width of the terminal is unknown
+const defaultBoardWidth = 100
+
+// Narrowest card of the board, wider boards than the terminal are left to wrap
+const minCardWidth = 12
+
+// Renders the columns of the board side by side, sized to the terminal width, or its cards in a machine-readable format.
+// With details, the tags and the due date are shown on every card. Columns over their WIP limit are flagged with a warning
+func (r *Renderer) Board(taskBoard *board.Board, details bool) error {
+	if !r.format.IsTable() {
+		return output.WriteRecords(r.w, r.format, taskBoard.Records())
+	}
+
+	width := r.terminalWidth
+	if width <= 0 {
+		width = defaultBoardWidth
+	}
+
+	// Every column is padded by a space on both sides and separated by a border
+	nbOfColumns := len(taskBoard.Columns)
+	cardWidth := max((width-1)/nbOfColumns-3, minCardWidth)
+	border := "+" + strings.Repeat(strings.Repeat("-", cardWidth+2)+"+", nbOfColumns)
+
+	headers := [][]string{}
+	cells := [][]string{}
+	for _, column := range taskBoard.Columns {
+		headers = append(headers, []string{column.Header()})
+		cells = append(cells, boardColumnLines(column, cardWidth, details))
+	}
+
+	r.Message(taskBoard.Title)
+	r.Message(border)
+	r.writeBoardRows(headers, cardWidth)
+	r.Message(border)
+	r.writeBoardRows(cells, cardWidth)
+	r.Message(border)
+
+	for _, warning := range taskBoard.Warnings() {
+		r.Messagef("Warning: %s", warning)
+	}
+
+	return nil
+}
+
+// Writes the lines of the columns side by side, padding the shorter columns
+func (r *Renderer) writeBoardRows(columns [][]string, cardWidth int) {
+	nbOfLines := 0
+	for _, lines := range columns {
+		nbOfLines = max(nbOfLines, len(lines))
+	}
+
+	for i := 0; i < nbOfLines; i++ {
+		var row strings.Builder
+		row.WriteString("|")
+		for _, lines := range columns {
+			line := ""
+			if i < len(lines) {
+				line = lines[i]
+			}
+			row.WriteString(" " + runewidth.FillRight(runewidth.Truncate(line, cardWidth, "…"), cardWidth) + " |")
+		}
+		r.Message(row.String())
+	}
+}
+
+// Returns the lines of the cards of the column, with the name wrapped to the width of the card
+func boardColumnLines(column board.Column, cardWidth int, details bool) []string {
+	lines := []string{}
+	for i, t := range column.Tasks {
+		if details && i > 0 {
+			lines = append(lines, "")
+		}
+
+		lines = append(lines, strings.Split(wrapText(fmt.Sprintf("#%d %s", t.Id, t.Name), cardWidth), "\n")...)
+
+		if !details {
+			continue
+		}
+
+		if len(t.Tags) > 0 {
+			for _, line := range strings.Split(wrapText("tags: "+strings.Join(t.Tags, ", "), cardWidth-2), "\n") {
+				lines = append(lines, "  "+line)
+			}
+		}
+
+		if t.DueDate != nil {
+			lines = append(lines, "  due: "+t.DueDate.Format(constants.DAY_FORMAT))
+		}
+	}
+
+	return lines
+}
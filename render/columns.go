@@ -0,0 +1,280 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/MuradIsayev/todo-tracker/config"
+	"github.com/MuradIsayev/todo-tracker/constants"
+	"github.com/MuradIsayev/todo-tracker/helpers"
+	"github.com/MuradIsayev/todo-tracker/task"
+	"github.com/mattn/go-runewidth"
+)
+
+// OVERFLOW MODES of long names:
+const (
+	OVERFLOW_WRAP     = "wrap"
+	OVERFLOW_TRUNCATE = "truncate"
+)
+
+// Narrowest width the name column is shrunk to when fitting the table to the terminal
+const minNameWidth = 10
+
+// Columns of the task table when none are configured
+var DefaultTaskColumns = []string{"id", "name", "status", "created", "updated", "spent"}
+
+// taskColumn is a column of the task table, its cell value, the order it sorts the tasks in
+// and the field of the task records it selects in the other formats
+type taskColumn struct {
+	header  string
+	field   string
+	value   func(t task.Task) string
+	compare func(a, b task.Task) int
+}
+
+var taskColumns = map[string]taskColumn{
+	"id": {
+		header:  constants.COLUMN_ID,
+		field:   "id",
+		value:   func(t task.Task) string { return strconv.Itoa(t.Id) },
+		compare: func(a, b task.Task) int { return a.Id - b.Id },
+	},
+	"name": {
+		header:  constants.COLUMN_NAME,
+		field:   "name",
+		value:   func(t task.Task) string { return t.Name },
+		compare: func(a, b task.Task) int { return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)) },
+	},
+	"status": {
+		header:  constants.COLUMN_STATUS,
+		field:   "status",
+		value:   func(t task.Task) string { return t.Status.String() },
+		compare: func(a, b task.Task) int { return int(a.Status) - int(b.Status) },
+	},
+	"tags": {
+		header:  constants.COLUMN_TAGS,
+		field:   "tags",
+		value:   func(t task.Task) string { return strings.Join(t.Tags, ", ") },
+		compare: func(a, b task.Task) int { return strings.Compare(strings.Join(a.Tags, ","), strings.Join(b.Tags, ",")) },
+	},
+	"due": {
+		header: constants.COLUMN_DUE_DATE,
+		field:  "dueDate",
+		value: func(t task.Task) string {
+			if t.DueDate == nil {
+				return ""
+			}
+			return t.DueDate.Format(constants.DAY_FORMAT)
+		},
+		// Tasks without a due date come after the tasks with one
+		compare: func(a, b task.Task) int {
+			switch {
+			case a.DueDate == nil && b.DueDate == nil:
+				return 0
+			case a.DueDate == nil:
+				return 1
+			case b.DueDate == nil:
+				return -1
+			}
+			return a.DueDate.Compare(*b.DueDate)
+		},
+	},
+	"created": {
+		header:  constants.COLUMN_CREATE_DATE,
+		field:   "createdAt",
+		value:   func(t task.Task) string { return t.CreatedAt.Format(constants.DATE_FORMAT) },
+		compare: func(a, b task.Task) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	},
+	"updated": {
+		header:  constants.COLUMN_UPDATE_DATE,
+		field:   "updatedAt",
+		value:   func(t task.Task) string { return t.UpdatedAt.Format(constants.DATE_FORMAT) },
+		compare: func(a, b task.Task) int { return a.UpdatedAt.Compare(b.UpdatedAt) },
+	},
+	"spent": {
+		header:  constants.COLUMN_TOTAL_SPENT_TIME,
+		field:   "totalSpentSeconds",
+		value:   func(t task.Task) string { return helpers.FormatSpendTime(t.TotalSpentTime) },
+		compare: func(a, b task.Task) int { return a.TotalSpentTime - b.TotalSpentTime },
+	},
+}
+
+// Returns the names of the columns of the task table, in the order of the default columns
+func TaskColumnNames() []string {
+	return append(append([]string{}, DefaultTaskColumns...), "tags", "due")
+}
+
+func findTaskColumn(name string) (taskColumn, error) {
+	column, exists := taskColumns[name]
+	if !exists {
+		return taskColumn{}, fmt.Errorf("unknown column %q, expected one of %s", name, strings.Join(TaskColumnNames(), ", "))
+	}
+
+	return column, nil
+}
+
+// Returns the fields of the task records selected by the columns of the table layout
+func taskFields(columnNames []string) ([]string, error) {
+	if len(columnNames) == 0 {
+		columnNames = DefaultTaskColumns
+	}
+
+	fields := []string{}
+	for _, name := range columnNames {
+		column, err := findTaskColumn(name)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, column.field)
+	}
+
+	return fields, nil
+}
+
+// Validates the layout of the task table
+func ValidateTaskTable(tableConfig config.TableConfig) error {
+	for _, name := range tableConfig.Columns {
+		if _, err := findTaskColumn(name); err != nil {
+			return err
+		}
+	}
+
+	if _, err := parseSortKeys(tableConfig.Sort); err != nil {
+		return err
+	}
+
+	if tableConfig.Overflow != "" && tableConfig.Overflow != OVERFLOW_WRAP && tableConfig.Overflow != OVERFLOW_TRUNCATE {
+		return fmt.Errorf("invalid overflow %q, expected %q or %q", tableConfig.Overflow, OVERFLOW_WRAP, OVERFLOW_TRUNCATE)
+	}
+
+	if tableConfig.MaxNameWidth < 0 {
+		return fmt.Errorf("maximum name width cannot be negative")
+	}
+
+	return nil
+}
+
+// sortKey orders the tasks by a column, descending if the key is prefixed with "-"
+type sortKey struct {
+	column     taskColumn
+	descending bool
+}
+
+// Parses comma-separated sort keys, e.g. "-spent,name"
+func parseSortKeys(value string) ([]sortKey, error) {
+	keys := []sortKey{}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		descending := strings.HasPrefix(name, "-")
+		column, err := findTaskColumn(strings.TrimPrefix(name, "-"))
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, sortKey{column: column, descending: descending})
+	}
+
+	return keys, nil
+}
+
+// Sorts the tasks by the comma-separated sort keys, keeping the stored order of equal tasks
+func SortTasks(tasks []task.Task, sortValue string) error {
+	keys, err := parseSortKeys(sortValue)
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(tasks, func(i, j int) bool {
+		for _, key := range keys {
+			result := key.column.compare(tasks[i], tasks[j])
+			if key.descending {
+				result = -result
+			}
+			if result != 0 {
+				return result < 0
+			}
+		}
+		return false
+	})
+
+	return nil
+}
+
+// Returns the width the name column may take so the table fits the maximum name width and the terminal width.
+// The widths of the other columns include their header and footer cells. Returns 0 if the width is unlimited
+func nameColumnWidth(widths []int, nameIndex int, maxNameWidth int, terminalWidth int) int {
+	width := maxNameWidth
+	if terminalWidth <= 0 {
+		return width
+	}
+
+	// Every column is padded by a space on both sides and separated by a border
+	available := terminalWidth - 3*len(widths) - 1
+	for i, columnWidth := range widths {
+		if i != nameIndex {
+			available -= columnWidth
+		}
+	}
+	available = max(available, minNameWidth)
+
+	if width == 0 || available < width {
+		width = available
+	}
+
+	return width
+}
+
+// Wraps the text at word boundaries into lines of the given display width, splitting words longer than a line
+func wrapText(text string, width int) string {
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for runewidth.StringWidth(word) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			head := runewidth.Truncate(word, width, "")
+			if head == "" {
+				break
+			}
+			lines = append(lines, head)
+			word = word[len(head):]
+		}
+
+		switch {
+		case line == "":
+			line = word
+		case runewidth.StringWidth(line)+1+runewidth.StringWidth(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Fits the name to the width, wrapping it over several lines or truncating it with an ellipsis
+func fitName(name string, width int, overflow string) string {
+	if width <= 0 || runewidth.StringWidth(name) <= width {
+		return name
+	}
+
+	if overflow == OVERFLOW_TRUNCATE {
+		return runewidth.Truncate(name, width, "…")
+	}
+
+	return wrapText(name, width)
+}
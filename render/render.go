@@ -0,0 +1,602 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MuradIsayev/todo-tracker/chart"
+	"github.com/MuradIsayev/todo-tracker/config"
+	"github.com/MuradIsayev/todo-tracker/constants"
+	"github.com/MuradIsayev/todo-tracker/goal"
+	"github.com/MuradIsayev/todo-tracker/helpers"
+	"github.com/MuradIsayev/todo-tracker/output"
+	"github.com/MuradIsayev/todo-tracker/project"
+	"github.com/MuradIsayev/todo-tracker/report"
+	"github.com/MuradIsayev/todo-tracker/search"
+	"github.com/MuradIsayev/todo-tracker/stats"
+	"github.com/MuradIsayev/todo-tracker/status"
+	"github.com/MuradIsayev/todo-tracker/task"
+	"github.com/MuradIsayev/todo-tracker/timer"
+	"github.com/mattn/go-runewidth"
+	"github.com/olekukonko/tablewriter"
+)
+
+// Renderer presents the results of the services on a writer, as bordered tables or in a machine-readable format
+type Renderer struct {
+	w             io.Writer
+	format        output.Format
+	terminalWidth int
+	taskTable     config.TableConfig
+	boardConfig   config.BoardConfig
+}
+
+func NewRenderer(w io.Writer, format output.Format) *Renderer {
+	return &Renderer{w: w, format: format}
+}
+
+// Returns the writer the renderer writes to
+func (r *Renderer) Writer() io.Writer {
+	return r.w
+}
+
+// Returns the output format of the renderer
+func (r *Renderer) Format() output.Format {
+	return r.format
+}
+
+// Sets the output format, e.g. from the --output flag of a command
+func (r *Renderer) SetFormat(format output.Format) {
+	r.format = format
+}
+
+// Sets the width tables are fitted to (0 for unlimited)
+func (r *Renderer) SetTerminalWidth(width int) {
+	r.terminalWidth = width
+}
+
+// Sets the default layout of the task table
+func (r *Renderer) SetTaskTable(tableConfig config.TableConfig) {
+	r.taskTable = tableConfig
+}
+
+// Returns the default layout of the task table
+func (r *Renderer) TaskTable() config.TableConfig {
+	return r.taskTable
+}
+
+// Sets the default layout of the kanban board
+func (r *Renderer) SetBoardConfig(boardConfig config.BoardConfig) {
+	r.boardConfig = boardConfig
+}
+
+// Returns the default layout of the kanban board
+func (r *Renderer) BoardConfig() config.BoardConfig {
+	return r.boardConfig
+}
+
+// Prints the message, e.g. the confirmation of a successful command
+func (r *Renderer) Message(message string) {
+	fmt.Fprintln(r.messageWriter(), message)
+}
+
+// Prints the formatted message
+func (r *Renderer) Messagef(format string, args ...any) {
+	fmt.Fprintf(r.messageWriter(), format+"\n", args...)
+}
+
+// Returns the writer of the messages, the standard error if the output is machine-readable so it stays parseable
+func (r *Renderer) messageWriter() io.Writer {
+	if r.format.IsTable() {
+		return r.w
+	}
+
+	return os.Stderr
+}
+
+func (r *Renderer) newTable(header []string) *tablewriter.Table {
+	table := tablewriter.NewWriter(r.w)
+	table.SetHeader(header)
+	table.SetRowLine(true)
+
+	return table
+}
+
+// Returns bold colors for the given number of columns
+func boldColumns(nbOfColumns int) []tablewriter.Colors {
+	colors := make([]tablewriter.Colors, nbOfColumns)
+	for i := range colors {
+		colors[i] = tablewriter.Colors{tablewriter.Bold}
+	}
+
+	return colors
+}
+
+func defineFooterText(nbOfLeftItems int, nbOfItems int, itemName string) string {
+	if nbOfLeftItems == 0 && nbOfItems == 0 {
+		return fmt.Sprintf("No %s found", itemName)
+	}
+
+	return fmt.Sprintf("Left %s: %d", itemName, nbOfLeftItems)
+}
+
+func (r *Renderer) Projects(projects []project.Project) error {
+	if !r.format.IsTable() {
+		records := []project.ProjectRecord{}
+		for _, p := range projects {
+			records = append(records, project.NewProjectRecord(p))
+		}
+
+		return output.WriteRecords(r.w, r.format, records)
+	}
+
+	table := r.newTable([]string{constants.COLUMN_ID, constants.COLUMN_NAME, constants.COLUMN_STATUS, constants.COLUMN_CREATE_DATE, constants.COLUMN_UPDATE_DATE, constants.COLUMN_TOTAL_SPENT_TIME, constants.COLUMN_TOTAL_TASKS, ""})
+
+	var nbOfLeftProjects int
+	for _, project := range projects {
+		createdAt := project.CreatedAt.Format(constants.DATE_FORMAT)
+		updatedAt := project.UpdatedAt.Format(constants.DATE_FORMAT)
+		totalSpentTime := helpers.FormatSpendTime(project.TotalSpentTime)
+
+		table.Append([]string{strconv.Itoa(project.Id), project.Name, project.Status.String(), createdAt, updatedAt, totalSpentTime, strconv.Itoa(project.NbOfTotalTasks), ""})
+		if project.Status == status.TODO {
+			nbOfLeftProjects++
+		}
+	}
+
+	table.SetFooter([]string{"", "", "", "", "", "", " ", defineFooterText(nbOfLeftProjects, len(projects), "projects")})
+	table.SetHeaderColor(boldColumns(8)...)
+	table.SetFooterColor(boldColumns(8)...)
+	table.Render()
+
+	return nil
+}
+
+// Renders the tasks sorted and with the columns of the table layout
+func (r *Renderer) Tasks(tasks []task.Task, tableConfig config.TableConfig) error {
+	if err := ValidateTaskTable(tableConfig); err != nil {
+		return err
+	}
+
+	if err := SortTasks(tasks, tableConfig.Sort); err != nil {
+		return err
+	}
+
+	if !r.format.IsTable() {
+		return r.taskRecords(tasks, tableConfig)
+	}
+
+	r.writeTaskTable(tasks, tableConfig)
+
+	return nil
+}
+
+// Renders the tasks of every project under the name of the project, sorted and with the columns of the table layout
+func (r *Renderer) ProjectTasks(groups []task.ProjectTasks, tableConfig config.TableConfig) error {
+	if err := ValidateTaskTable(tableConfig); err != nil {
+		return err
+	}
+
+	tasks := []task.Task{}
+	for _, group := range groups {
+		if err := SortTasks(group.Tasks, tableConfig.Sort); err != nil {
+			return err
+		}
+
+		tasks = append(tasks, group.Tasks...)
+	}
+
+	if !r.format.IsTable() {
+		return r.taskRecords(tasks, tableConfig)
+	}
+
+	if len(groups) == 0 {
+		r.Message("No tasks found")
+		return nil
+	}
+
+	for _, group := range groups {
+		r.Messagef("Project %d: %s", group.Project.Id, group.Project.Name)
+		r.writeTaskTable(group.Tasks, tableConfig)
+		r.Message("")
+	}
+
+	r.Messagef("Found %d task(s) in %d project(s)", len(tasks), len(groups))
+
+	return nil
+}
+
+// Writes the tasks as records with the fields of the columns of the table layout
+func (r *Renderer) taskRecords(tasks []task.Task, tableConfig config.TableConfig) error {
+	fields, err := taskFields(tableConfig.Columns)
+	if err != nil {
+		return err
+	}
+
+	records := []task.TaskRecord{}
+	for _, t := range tasks {
+		records = append(records, task.NewTaskRecord(t))
+	}
+
+	return output.WriteRecordFields(r.w, r.format, records, fields)
+}
+
+func (r *Renderer) writeTaskTable(tasks []task.Task, tableConfig config.TableConfig) {
+	columnNames := tableConfig.Columns
+	if len(columnNames) == 0 {
+		columnNames = DefaultTaskColumns
+	}
+
+	header := []string{}
+	columns := []taskColumn{}
+	nameIndex := -1
+	for i, name := range columnNames {
+		column, _ := findTaskColumn(name)
+		if name == "name" {
+			nameIndex = i
+		}
+
+		header = append(header, column.header)
+		columns = append(columns, column)
+	}
+
+	var nbOfLeftTasks int
+	rows := [][]string{}
+	for _, task := range tasks {
+		row := []string{}
+		for _, column := range columns {
+			row = append(row, column.value(task))
+		}
+
+		rows = append(rows, row)
+		if task.Status == status.TODO {
+			nbOfLeftTasks++
+		}
+	}
+
+	footer := make([]string, len(columns))
+	if !tableConfig.HideFooter {
+		footer[len(footer)-1] = defineFooterText(nbOfLeftTasks, len(tasks), "tasks")
+	}
+
+	if nameIndex != -1 {
+		widths := columnWidths(header, rows, footer)
+		nameWidth := nameColumnWidth(widths, nameIndex, tableConfig.MaxNameWidth, r.terminalWidth)
+		for _, row := range rows {
+			row[nameIndex] = fitName(row[nameIndex], nameWidth, tableConfig.Overflow)
+		}
+	}
+
+	table := r.newTable(header)
+	table.SetAutoWrapText(false)
+	table.AppendBulk(rows)
+	if !tableConfig.HideFooter {
+		table.SetFooter(footer)
+		table.SetFooterColor(boldColumns(len(columns))...)
+	}
+	table.SetHeaderColor(boldColumns(len(columns))...)
+	table.Render()
+}
+
+// Returns the display width of every column, the widest of its header, row and footer cells
+func columnWidths(header []string, rows [][]string, footer []string) []int {
+	widths := make([]int, len(header))
+	for _, cells := range append([][]string{header, footer}, rows...) {
+		for i, cell := range cells {
+			widths[i] = max(widths[i], runewidth.StringWidth(cell))
+		}
+	}
+
+	return widths
+}
+
+func (r *Renderer) SearchResults(results []search.Result) error {
+	if !r.format.IsTable() {
+		return output.WriteRecords(r.w, r.format, results)
+	}
+
+	if len(results) == 0 {
+		r.Message("No results found")
+		return nil
+	}
+
+	table := r.newTable([]string{constants.COLUMN_TYPE, constants.COLUMN_PROJECT, constants.COLUMN_ID, constants.COLUMN_NAME, constants.COLUMN_SCORE})
+	for _, result := range results {
+		table.Append([]string{result.Kind, strconv.Itoa(result.ProjectId), strconv.Itoa(result.Id), result.Name, strconv.FormatFloat(result.Score, 'f', 2, 64)})
+	}
+	table.Render()
+
+	return nil
+}
+
+func formatGoalProjectColumn(projectId int) string {
+	if projectId == 0 {
+		return "All projects"
+	}
+
+	return strconv.Itoa(projectId)
+}
+
+func (r *Renderer) Goals(goals []goal.Goal) error {
+	if !r.format.IsTable() {
+		records := []goal.GoalRecord{}
+		for _, g := range goals {
+			records = append(records, goal.NewGoalRecord(g))
+		}
+
+		return output.WriteRecords(r.w, r.format, records)
+	}
+
+	table := r.newTable([]string{constants.COLUMN_ID, constants.COLUMN_PERIOD, constants.COLUMN_TARGET, constants.COLUMN_PROJECT, constants.COLUMN_CREATE_DATE, constants.COLUMN_UPDATE_DATE})
+
+	for _, goal := range goals {
+		table.Append([]string{
+			strconv.Itoa(goal.Id),
+			goal.Period,
+			helpers.FormatSpendTime(goal.TargetSeconds),
+			formatGoalProjectColumn(goal.ProjectId),
+			goal.CreatedAt.Format(constants.DATE_FORMAT),
+			goal.UpdatedAt.Format(constants.DATE_FORMAT),
+		})
+	}
+
+	if len(goals) == 0 {
+		table.SetFooter([]string{"", "", "", "", "", "No goals found"})
+	}
+
+	table.Render()
+
+	return nil
+}
+
+func (r *Renderer) GoalProgress(progress []goal.GoalProgress) error {
+	if !r.format.IsTable() {
+		records := []goal.GoalProgressRecord{}
+		for _, p := range progress {
+			records = append(records, goal.NewGoalProgressRecord(p))
+		}
+
+		return output.WriteRecords(r.w, r.format, records)
+	}
+
+	table := r.newTable([]string{constants.COLUMN_ID, constants.COLUMN_PERIOD, constants.COLUMN_PROJECT, constants.COLUMN_TARGET, constants.COLUMN_FOCUSED, constants.COLUMN_PROGRESS})
+
+	var nbOfReachedGoals int
+	for _, p := range progress {
+		table.Append([]string{
+			strconv.Itoa(p.Goal.Id),
+			p.Goal.Period,
+			formatGoalProjectColumn(p.Goal.ProjectId),
+			helpers.FormatSpendTime(p.Goal.TargetSeconds),
+			helpers.FormatSpendTime(p.FocusedSeconds),
+			fmt.Sprintf("%s %d%%", helpers.ProgressBar(p.Percent(0), 20), p.Percent(0)),
+		})
+
+		if p.IsReached(0) {
+			nbOfReachedGoals++
+		}
+	}
+
+	footerText := fmt.Sprintf("Reached goals: %d/%d", nbOfReachedGoals, len(progress))
+	if len(progress) == 0 {
+		footerText = "No goals found"
+	}
+
+	table.SetFooter([]string{"", "", "", "", "", footerText})
+	table.Render()
+
+	return nil
+}
+
+func groupColumnName(groupBy string) string {
+	switch groupBy {
+	case report.GROUP_BY_DAY:
+		return constants.COLUMN_DAY
+	case report.GROUP_BY_WEEK:
+		return constants.COLUMN_WEEK
+	case report.GROUP_BY_PROJECT:
+		return constants.COLUMN_PROJECT
+	case report.GROUP_BY_TASK:
+		return constants.COLUMN_TASK
+	default:
+		return constants.COLUMN_TAG
+	}
+}
+
+func (r *Renderer) Report(focusReport *report.Report) error {
+	if !r.format.IsTable() {
+		record := report.NewReportRecord(focusReport)
+		if r.format == output.JSON {
+			return output.WriteJSON(r.w, record)
+		}
+
+		return output.WriteRecords(r.w, r.format, record.Rows)
+	}
+
+	table := r.newTable([]string{groupColumnName(focusReport.GroupBy), constants.COLUMN_SESSIONS, constants.COLUMN_FOCUSED, constants.COLUMN_SHARE})
+
+	for _, row := range focusReport.Rows {
+		table.Append([]string{row.Label, strconv.Itoa(row.Sessions), helpers.FormatSpendTime(row.FocusedSeconds), fmt.Sprintf("%d%%", focusReport.SharePercent(row))})
+	}
+
+	footerText := fmt.Sprintf("%s - %s", focusReport.From.Format(constants.DAY_FORMAT), focusReport.To.AddDate(0, 0, -1).Format(constants.DAY_FORMAT))
+	if len(focusReport.Rows) == 0 {
+		footerText = "No focused time found"
+	}
+
+	table.SetFooter([]string{"Total", strconv.Itoa(focusReport.TotalSessions), helpers.FormatSpendTime(focusReport.TotalSeconds), footerText})
+	table.Render()
+
+	return nil
+}
+
+// Renders the stats with a heatmap of the last weeks up to today
+func (r *Renderer) Stats(trackerStats *stats.Stats, today time.Time, nbOfWeeks int, unicode bool) error {
+	if r.format == output.JSON {
+		return output.WriteJSON(r.w, trackerStats)
+	}
+
+	// The other formats list the periods and the most worked projects as two blocks separated by an empty line
+	if !r.format.IsTable() {
+		if err := output.WriteRecords(r.w, r.format, trackerStats.Periods); err != nil {
+			return err
+		}
+
+		fmt.Fprintln(r.w)
+
+		return output.WriteRecords(r.w, r.format, trackerStats.TopProjects)
+	}
+
+	periodTable := r.newTable([]string{constants.COLUMN_PERIOD, constants.COLUMN_CREATED_TASKS, constants.COLUMN_COMPLETED_TASKS, constants.COLUMN_FOCUSED})
+	for _, period := range trackerStats.Periods {
+		periodTable.Append([]string{period.Label, strconv.Itoa(period.CreatedTasks), strconv.Itoa(period.CompletedTasks), helpers.FormatSpendTime(period.FocusedSeconds)})
+	}
+	periodTable.Render()
+
+	fmt.Fprintf(r.w, "Current streak: %d day(s), longest streak: %d day(s)\n", trackerStats.CurrentStreak, trackerStats.LongestStreak)
+	fmt.Fprintf(r.w, "Sessions: %d, average session length: %s\n\n", trackerStats.NbOfSessions, strings.TrimSpace(helpers.FormatSpendTime(trackerStats.AverageSessionLength)))
+
+	projectTable := r.newTable([]string{constants.COLUMN_PROJECT, constants.COLUMN_FOCUSED, constants.COLUMN_OPEN_TASKS})
+	for _, project := range trackerStats.TopProjects {
+		projectTable.Append([]string{project.Name, helpers.FormatSpendTime(project.FocusedSeconds), fmt.Sprintf("%d/%d", project.OpenTasks, project.TotalTasks)})
+	}
+
+	if len(trackerStats.TopProjects) == 0 {
+		projectTable.SetFooter([]string{"", "", "No focused time found"})
+	}
+
+	projectTable.Render()
+
+	fmt.Fprintln(r.w)
+	fmt.Fprint(r.w, Heatmap(trackerStats.FocusedSecondsByDay, today, nbOfWeeks, unicode))
+
+	return nil
+}
+
+// Renders the focused time per day of the last weeks as a calendar heatmap, one column per week and one row per weekday
+func Heatmap(focusedSecondsByDay map[string]int, today time.Time, nbOfWeeks int, unicode bool) string {
+	shades := []string{"·", "░", "▒", "▓", "█"}
+	if !unicode {
+		shades = []string{".", "-", "+", "*", "#"}
+	}
+
+	firstWeek := helpers.StartOfWeek(today).AddDate(0, 0, -7*(nbOfWeeks-1))
+
+	maximum := 0
+	for week := 0; week < nbOfWeeks; week++ {
+		for weekday := 0; weekday < 7; weekday++ {
+			maximum = max(maximum, focusedSecondsByDay[firstWeek.AddDate(0, 0, 7*week+weekday).Format(constants.DAY_FORMAT)])
+		}
+	}
+
+	var heatmap strings.Builder
+
+	// Month labels above the first week of each month
+	monthLine := []rune(strings.Repeat(" ", nbOfWeeks+4))
+	labelEnd := 0
+	for week := 0; week < nbOfWeeks; week++ {
+		weekStart := firstWeek.AddDate(0, 0, 7*week)
+		if week == 0 || weekStart.Day() <= 7 {
+			label := []rune(weekStart.Format("Jan"))
+			if week+4 > labelEnd && week+4+len(label) <= len(monthLine) {
+				copy(monthLine[week+4:], label)
+				labelEnd = week + 4 + len(label)
+			}
+		}
+	}
+	heatmap.WriteString(strings.TrimRight(string(monthLine), " ") + "\n")
+
+	weekdayLabels := []string{"Mon", "   ", "Wed", "   ", "Fri", "   ", "Sun"}
+	for weekday := 0; weekday < 7; weekday++ {
+		heatmap.WriteString(weekdayLabels[weekday] + " ")
+
+		for week := 0; week < nbOfWeeks; week++ {
+			day := firstWeek.AddDate(0, 0, 7*week+weekday)
+			if day.After(today) {
+				heatmap.WriteString(" ")
+				continue
+			}
+
+			seconds := focusedSecondsByDay[day.Format(constants.DAY_FORMAT)]
+			level := 0
+			if seconds > 0 && maximum > 0 {
+				level = 1 + min(seconds*4/maximum, 3)
+			}
+
+			heatmap.WriteString(shades[level])
+		}
+
+		heatmap.WriteString("\n")
+	}
+
+	heatmap.WriteString(fmt.Sprintf("Less %s More (max %s per day)\n", strings.Join(shades, ""), strings.TrimSpace(helpers.FormatSpendTime(maximum))))
+
+	return heatmap.String()
+}
+
+// Renders the burndown chart as columns, or its points in a machine-readable format
+func (r *Renderer) Burndown(burndown *chart.Chart, unicode bool) error {
+	if !r.format.IsTable() {
+		return output.WriteRecords(r.w, r.format, burndown.Points)
+	}
+
+	chart.RenderColumns(r.w, burndown, unicode)
+
+	return nil
+}
+
+// Renders the velocity chart as bars, or its points in a machine-readable format
+func (r *Renderer) Velocity(velocity *chart.Chart, unicode bool) error {
+	if !r.format.IsTable() {
+		return output.WriteRecords(r.w, r.format, velocity.Points)
+	}
+
+	chart.RenderBars(r.w, velocity, unicode)
+
+	return nil
+}
+
+// Renders the active timer (nil if no timer is running)
+func (r *Renderer) TimerStatus(activeTimer *timer.ActiveTimer, now time.Time) error {
+	if !r.format.IsTable() {
+		records := []timer.TimerStatusRecord{}
+		if activeTimer != nil {
+			records = append(records, timer.NewTimerStatusRecord(activeTimer, now))
+		}
+
+		// JSON describes the single active timer, or null if there is none
+		if r.format == output.JSON {
+			var record any
+			if len(records) > 0 {
+				record = records[0]
+			}
+
+			return output.WriteJSON(r.w, record)
+		}
+
+		return output.WriteRecords(r.w, r.format, records)
+	}
+
+	if activeTimer == nil {
+		r.Message("No timer is running")
+		return nil
+	}
+
+	state := "running"
+	if activeTimer.Paused {
+		state = "paused"
+	}
+
+	r.Messagef("Task --> \"%s\" (project ID=%d): %s, %s focused since %s",
+		activeTimer.TaskName,
+		activeTimer.ProjectId,
+		state,
+		strings.TrimSpace(helpers.FormatSpendTime(activeTimer.ElapsedSeconds(now))),
+		activeTimer.StartedAt.Format(constants.DATE_FORMAT),
+	)
+
+	return nil
+}
@@ -0,0 +1,283 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/MuradIsayev/todo-tracker/constants"
+	"github.com/MuradIsayev/todo-tracker/helpers"
+	"github.com/MuradIsayev/todo-tracker/project"
+	"github.com/MuradIsayev/todo-tracker/session"
+	"github.com/MuradIsayev/todo-tracker/task"
+)
+
+// REPORT GROUPINGS:
+const (
+	GROUP_BY_DAY     = "day"
+	GROUP_BY_WEEK    = "week"
+	GROUP_BY_PROJECT = "project"
+	GROUP_BY_TASK    = "task"
+	GROUP_BY_TAG     = "tag"
+)
+
+const untaggedLabel = "(untagged)"
+
+// ReportRow is the focused time aggregated for one group of the report
+type ReportRow struct {
+	Label          string
+	Sessions       int
+	FocusedSeconds int
+	sortKey        string
+}
+
+// Report is the focused time within [From, To) aggregated by the GroupBy grouping
+type Report struct {
+	From          time.Time
+	To            time.Time
+	GroupBy       string
+	Rows          []ReportRow
+	TotalSessions int
+	TotalSeconds  int
+}
+
+// ReportRowRecord is the machine-readable representation of a row of the report
+type ReportRowRecord struct {
+	Label          string `json:"label"`
+	Sessions       int    `json:"sessions"`
+	FocusedSeconds int    `json:"focusedSeconds"`
+	SharePercent   int    `json:"sharePercent"`
+}
+
+// ReportRecord is the machine-readable representation of the report, [From, To) being the reported range
+type ReportRecord struct {
+	From          time.Time         `json:"from"`
+	To            time.Time         `json:"to"`
+	GroupBy       string            `json:"groupBy"`
+	TotalSessions int               `json:"totalSessions"`
+	TotalSeconds  int               `json:"totalSeconds"`
+	Rows          []ReportRowRecord `json:"rows"`
+}
+
+// Returns the share of the focused time of the row in the report, in percent
+func (r *Report) SharePercent(row ReportRow) int {
+	if r.TotalSeconds == 0 {
+		return 0
+	}
+
+	return row.FocusedSeconds * 100 / r.TotalSeconds
+}
+
+func NewReportRecord(report *Report) ReportRecord {
+	record := ReportRecord{
+		From:          report.From,
+		To:            report.To,
+		GroupBy:       report.GroupBy,
+		TotalSessions: report.TotalSessions,
+		TotalSeconds:  report.TotalSeconds,
+		Rows:          []ReportRowRecord{},
+	}
+
+	for _, row := range report.Rows {
+		record.Rows = append(record.Rows, ReportRowRecord{Label: row.Label, Sessions: row.Sessions, FocusedSeconds: row.FocusedSeconds, SharePercent: report.SharePercent(row)})
+	}
+
+	return record
+}
+
+type ReportService struct {
+	sessionService *session.SessionService
+	projectService *project.ProjectService
+	taskService    *task.TaskService
+}
+
+func NewReportService(sessionService *session.SessionService, projectService *project.ProjectService, taskService *task.TaskService) *ReportService {
+	return &ReportService{
+		sessionService: sessionService,
+		projectService: projectService,
+		taskService:    taskService,
+	}
+}
+
+// Describes a part of a session that belongs to one group of the report
+type groupShare struct {
+	key     string
+	label   string
+	seconds int
+}
+
+// Aggregates the focused time of the sessions within [from, to). A projectId of 0 includes all projects
+func (s *ReportService) BuildReport(from, to time.Time, groupBy string, projectId int) (*Report, error) {
+	if groupBy != GROUP_BY_DAY && groupBy != GROUP_BY_WEEK && groupBy != GROUP_BY_PROJECT && groupBy != GROUP_BY_TASK && groupBy != GROUP_BY_TAG {
+		return nil, fmt.Errorf("invalid grouping %q, expected day, week, project, task or tag", groupBy)
+	}
+
+	if !to.After(from) {
+		return nil, fmt.Errorf("the end of the range must be after its start")
+	}
+
+	sessions, err := s.sessionService.FindSessions(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	projectNames, tasks, err := s.loadProjectsAndTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{From: from, To: to, GroupBy: groupBy}
+	rows := map[string]*ReportRow{}
+
+	for _, session := range sessions {
+		if projectId != 0 && session.ProjectId != projectId {
+			continue
+		}
+
+		secondsInRange := session.DurationWithin(from, to)
+		if secondsInRange == 0 {
+			continue
+		}
+
+		report.TotalSessions++
+		report.TotalSeconds += secondsInRange
+
+		for _, share := range splitSession(session, from, to, groupBy, projectNames, tasks) {
+			if share.seconds == 0 {
+				continue
+			}
+
+			row, ok := rows[share.key]
+			if !ok {
+				row = &ReportRow{Label: share.label, sortKey: share.key}
+				rows[share.key] = row
+			}
+
+			row.Sessions++
+			row.FocusedSeconds += share.seconds
+		}
+	}
+
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+
+	sort.Slice(report.Rows, func(i, j int) bool {
+		// Periods are listed chronologically, everything else by the most focused time
+		if groupBy == GROUP_BY_DAY || groupBy == GROUP_BY_WEEK || report.Rows[i].FocusedSeconds == report.Rows[j].FocusedSeconds {
+			return report.Rows[i].sortKey < report.Rows[j].sortKey
+		}
+
+		return report.Rows[i].FocusedSeconds > report.Rows[j].FocusedSeconds
+	})
+
+	return report, nil
+}
+
+// Splits the focused time of the session within [from, to) into the groups of the report
+func splitSession(session session.Session, from, to time.Time, groupBy string, projectNames map[int]string, tasks map[int]map[int]task.Task) []groupShare {
+	switch groupBy {
+	case GROUP_BY_DAY, GROUP_BY_WEEK:
+		shares := []groupShare{}
+		periodStart := helpers.StartOfDay(session.StartedAt)
+		if groupBy == GROUP_BY_WEEK {
+			periodStart = helpers.StartOfWeek(session.StartedAt)
+		}
+
+		for periodStart.Before(session.EndedAt) {
+			periodEnd := periodStart.AddDate(0, 0, 1)
+			label := periodStart.Format(constants.DAY_FORMAT)
+			if groupBy == GROUP_BY_WEEK {
+				periodEnd = periodStart.AddDate(0, 0, 7)
+				label = fmt.Sprintf("%s - %s", periodStart.Format(constants.DAY_FORMAT), periodEnd.AddDate(0, 0, -1).Format(constants.DAY_FORMAT))
+			}
+
+			shares = append(shares, groupShare{
+				key:     periodStart.Format(constants.DAY_FORMAT),
+				label:   label,
+				seconds: session.DurationWithin(maxTime(from, periodStart), minTime(to, periodEnd)),
+			})
+			periodStart = periodEnd
+		}
+
+		return shares
+	case GROUP_BY_PROJECT:
+		return []groupShare{{
+			key:     strconv.Itoa(session.ProjectId),
+			label:   formatProjectLabel(session.ProjectId, projectNames),
+			seconds: session.DurationWithin(from, to),
+		}}
+	case GROUP_BY_TASK:
+		return []groupShare{{
+			key:     fmt.Sprintf("%d/%d", session.ProjectId, session.TaskId),
+			label:   fmt.Sprintf("%s / %s", formatProjectLabel(session.ProjectId, projectNames), formatTaskLabel(session, tasks)),
+			seconds: session.DurationWithin(from, to),
+		}}
+	default:
+		tags := tasks[session.ProjectId][session.TaskId].Tags
+		if len(tags) == 0 {
+			tags = []string{untaggedLabel}
+		}
+
+		// A session of a task with several tags counts towards each of its tags
+		shares := []groupShare{}
+		for _, tag := range tags {
+			shares = append(shares, groupShare{key: tag, label: tag, seconds: session.DurationWithin(from, to)})
+		}
+
+		return shares
+	}
+}
+
+// Loads the project names and the tasks of all projects (by project ID and task ID)
+func (s *ReportService) loadProjectsAndTasks() (map[int]string, map[int]map[int]task.Task, error) {
+	projects, err := s.projectService.FindProjects()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	projectNames := map[int]string{}
+	for _, project := range projects {
+		projectNames[project.Id] = project.Name
+	}
+
+	tasks, err := s.taskService.FindTasksOfAllProjects()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return projectNames, tasks, nil
+}
+
+func formatProjectLabel(projectId int, projectNames map[int]string) string {
+	if name, ok := projectNames[projectId]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Project ID=%d (deleted)", projectId)
+}
+
+func formatTaskLabel(session session.Session, tasks map[int]map[int]task.Task) string {
+	if task, ok := tasks[session.ProjectId][session.TaskId]; ok {
+		return task.Name
+	}
+
+	return fmt.Sprintf("Task ID=%d (deleted)", session.TaskId)
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,149 @@
+package script
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/MuradIsayev/todo-tracker/cli"
+)
+
+// Returned by the command runner to end the script early without an error, e.g. by the exit command of the REPL mode
+var ErrExit = errors.New("exit")
+
+// Keyword of the lines setting a variable, e.g. `set PROJECT "Website"`
+const SET_KEYWORD = "set"
+
+// References to variables, $NAME or ${NAME}
+var variableRegex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+var variableNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Options of a script run
+type Options struct {
+	// Name of the script shown in the location of the errors, e.g. the file name
+	Name string
+	// Stops the script at the first failing command
+	StopOnError bool
+	// Initial variables, e.g. given on the command line
+	Variables map[string]string
+	// Reports the error of the command at the location, e.g. "template.tt:3"
+	OnError func(location string, err error)
+}
+
+// Result summarises a script run
+type Result struct {
+	Commands int
+	Failed   int
+	// Location of the failing command the script was stopped at, empty if the script ran to its end
+	StoppedAt string
+}
+
+// Runs the commands of the script line by line. Empty lines and lines starting with # are skipped,
+// `set NAME value` sets a variable and $NAME or ${NAME} is replaced by its value before the line is split into arguments
+func Run(in io.Reader, options Options, runCommand func(args []string) error) (Result, error) {
+	variables := map[string]string{}
+	for name, value := range options.Variables {
+		variables[name] = value
+	}
+
+	result := Result{}
+	scanner := bufio.NewScanner(in)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		location := fmt.Sprintf("%s:%d", options.Name, lineNumber)
+		result.Commands++
+
+		err := runLine(line, variables, runCommand)
+		if errors.Is(err, ErrExit) {
+			break
+		}
+
+		if err != nil {
+			result.Failed++
+			if options.OnError != nil {
+				options.OnError(location, err)
+			}
+
+			if options.StopOnError {
+				result.StoppedAt = location
+				break
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("cannot read %s: %v", options.Name, err)
+	}
+
+	return result, nil
+}
+
+func runLine(line string, variables map[string]string, runCommand func(args []string) error) error {
+	expanded, err := expandVariables(line, variables)
+	if err != nil {
+		return err
+	}
+
+	args, err := cli.SplitArgs(expanded)
+	if err != nil || len(args) == 0 {
+		return err
+	}
+
+	if args[0] != SET_KEYWORD {
+		return runCommand(args)
+	}
+
+	if len(args) < 3 || !variableNameRegex.MatchString(args[1]) {
+		return errors.New("expected `set NAME value`")
+	}
+
+	variables[args[1]] = strings.Join(args[2:], " ")
+
+	return nil
+}
+
+// Replaces the references to variables by their values. A reference to an unset variable is an error
+func expandVariables(line string, variables map[string]string) (string, error) {
+	var missing []string
+
+	expanded := variableRegex.ReplaceAllStringFunc(line, func(reference string) string {
+		name := strings.Trim(reference, "${}")
+		value, exists := variables[name]
+		if !exists {
+			missing = append(missing, name)
+		}
+
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("undefined variable %s", strings.Join(missing, ", "))
+	}
+
+	return expanded, nil
+}
+
+// Parses the variables given as NAME=value
+func ParseVariables(assignments []string) (map[string]string, error) {
+	variables := map[string]string{}
+	for _, assignment := range assignments {
+		name, value, found := strings.Cut(assignment, "=")
+		if !found || !variableNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("invalid variable %q, expected NAME=value", assignment)
+		}
+
+		variables[name] = value
+	}
+
+	return variables, nil
+}
@@ -0,0 +1,143 @@
+package script
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const testScript = `# Creates the tasks of a release
+set PROJECT "Website v2"
+
+task -p "$PROJECT" add Write docs
+fail first
+task -p ${PROJECT} add Deploy
+fail second
+`
+
+// Records the commands it runs, failing the commands named fail
+func newTestRunner(commands *[]string) func(args []string) error {
+	return func(args []string) error {
+		*commands = append(*commands, strings.Join(args, "|"))
+		if args[0] == "fail" {
+			return errors.New(args[1])
+		}
+		return nil
+	}
+}
+
+func TestRun(t *testing.T) {
+	for _, test := range []struct {
+		stopOnError bool
+		commands    string
+		errors      string
+		result      Result
+	}{
+		{
+			stopOnError: false,
+			commands:    "task|-p|Website v2|add|Write|docs,fail|first,task|-p|Website|v2|add|Deploy,fail|second",
+			errors:      "release.tt:5: first,release.tt:7: second",
+			result:      Result{Commands: 5, Failed: 2},
+		},
+		{
+			stopOnError: true,
+			commands:    "task|-p|Website v2|add|Write|docs,fail|first",
+			errors:      "release.tt:5: first",
+			result:      Result{Commands: 3, Failed: 1, StoppedAt: "release.tt:5"},
+		},
+	} {
+		commands := []string{}
+		errs := []string{}
+		options := Options{
+			Name:        "release.tt",
+			StopOnError: test.stopOnError,
+			OnError: func(location string, err error) {
+				errs = append(errs, fmt.Sprintf("%s: %v", location, err))
+			},
+		}
+
+		result, err := Run(strings.NewReader(testScript), options, newTestRunner(&commands))
+		if err != nil {
+			t.Fatalf("stop on error %v: %v", test.stopOnError, err)
+		}
+
+		if got := strings.Join(commands, ","); got != test.commands {
+			t.Errorf("stop on error %v: commands = %q, want %q", test.stopOnError, got, test.commands)
+		}
+
+		if got := strings.Join(errs, ","); got != test.errors {
+			t.Errorf("stop on error %v: errors = %q, want %q", test.stopOnError, got, test.errors)
+		}
+
+		if result != test.result {
+			t.Errorf("stop on error %v: result = %+v, want %+v", test.stopOnError, result, test.result)
+		}
+	}
+}
+
+func TestRunReportsInvalidLines(t *testing.T) {
+	for _, test := range []struct {
+		line  string
+		error string
+	}{
+		{"task add $MISSING", "undefined variable MISSING"},
+		{"set PROJECT", "expected `set NAME value`"},
+		{"set 1PROJECT Website", "expected `set NAME value`"},
+		{`task add "Write docs`, "unterminated"},
+	} {
+		commands := []string{}
+		var got error
+		options := Options{Name: "-", StopOnError: true, OnError: func(location string, err error) { got = err }}
+
+		result, err := Run(strings.NewReader(test.line+"\ntask list\n"), options, newTestRunner(&commands))
+		if err != nil {
+			t.Fatalf("%q: %v", test.line, err)
+		}
+
+		if got == nil || !strings.Contains(got.Error(), test.error) {
+			t.Errorf("%q: error = %v, want %q", test.line, got, test.error)
+		}
+
+		if result.StoppedAt != "-:1" || len(commands) != 0 {
+			t.Errorf("%q: stopped at %q after %q, want stopped at -:1 before any command", test.line, result.StoppedAt, commands)
+		}
+	}
+}
+
+func TestRunEndsAtExit(t *testing.T) {
+	commands := []string{}
+	runCommand := func(args []string) error {
+		commands = append(commands, args[0])
+		if args[0] == "exit" {
+			return ErrExit
+		}
+		return nil
+	}
+
+	result, err := Run(strings.NewReader("list\nexit\nlist\n"), Options{Name: "-"}, runCommand)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(commands, ","); got != "list,exit" || result.Failed != 0 || result.StoppedAt != "" {
+		t.Errorf("commands = %q, result = %+v, want the script ended at exit without an error", got, result)
+	}
+}
+
+func TestParseVariables(t *testing.T) {
+	variables, err := ParseVariables([]string{"PROJECT=Website", "EMPTY=", "QUERY=a=b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fmt.Sprint(variables); got != "map[EMPTY: PROJECT:Website QUERY:a=b]" {
+		t.Errorf("variables = %s", got)
+	}
+
+	for _, assignment := range []string{"PROJECT", "=Website", "1PROJECT=Website", "MY-PROJECT=Website"} {
+		if _, err := ParseVariables([]string{assignment}); err == nil {
+			t.Errorf("ParseVariables(%q) succeeded, want an error", assignment)
+		}
+	}
+}
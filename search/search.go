@@ -0,0 +1,357 @@
+package search
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DOCUMENT KINDS:
+const (
+	KIND_PROJECT = "project"
+	KIND_TASK    = "task"
+)
+
+// Bumped whenever the layout of the index file changes, so outdated indexes are rebuilt
+const indexVersion = 1
+
+// Weights of a query term matching a term of a document exactly, as a prefix or with a typo
+const (
+	exactWeight  = 1.0
+	prefixWeight = 0.7
+	fuzzyWeight  = 0.4
+)
+
+// Document is the searchable text of a project or a task, reduced to the frequency of its terms
+type Document struct {
+	Kind      string         `json:"kind"`
+	ProjectId int            `json:"projectId"`
+	Id        int            `json:"id"`
+	Name      string         `json:"name"`
+	Terms     map[string]int `json:"terms"`
+}
+
+// Indexable is implemented by the items stored by a BaseService that are kept in the search index
+type Indexable interface {
+	SearchDocument() Document
+}
+
+func NewDocument(kind string, projectId int, id int, name string) *Document {
+	return &Document{Kind: kind, ProjectId: projectId, Id: id, Name: name, Terms: map[string]int{}}
+}
+
+// Adds the terms of the text to the document, each occurrence counting weight times
+func (d *Document) AddText(text string, weight int) {
+	for _, term := range Tokenize(text) {
+		d.Terms[term] += weight
+	}
+}
+
+func (d *Document) key() string {
+	return fmt.Sprintf("%s:%d:%d", d.Kind, d.ProjectId, d.Id)
+}
+
+// Result is a document matching a search, with its relevance score
+type Result struct {
+	Kind      string  `json:"kind"`
+	ProjectId int     `json:"projectId"`
+	Id        int     `json:"id"`
+	Name      string  `json:"name"`
+	Score     float64 `json:"score"`
+}
+
+// indexData is the content of the index file: the documents of every indexed file and the documents containing each term
+type indexData struct {
+	Version  int                   `json:"version"`
+	Files    map[string][]Document `json:"files"`
+	Postings map[string][]string   `json:"postings"`
+}
+
+// Index is an inverted index over the projects and tasks, stored in a file and updated on every write of the indexed files
+type Index struct {
+	FilePath string
+}
+
+func NewIndex(filePath string) *Index {
+	return &Index{FilePath: filePath}
+}
+
+// Splits the text into lowercase terms of letters and digits, skipping single characters
+func Tokenize(text string) []string {
+	terms := []string{}
+	for _, term := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(term)) > 1 {
+			terms = append(terms, term)
+		}
+	}
+
+	return terms
+}
+
+// Loads the index, or returns false if it is missing, unreadable or outdated
+func (i *Index) load() (*indexData, bool) {
+	fileContent, err := os.ReadFile(i.FilePath)
+	if err != nil {
+		return nil, false
+	}
+
+	data := &indexData{}
+	if err := json.Unmarshal(fileContent, data); err != nil || data.Version != indexVersion {
+		return nil, false
+	}
+
+	return data, true
+}
+
+func (i *Index) save(data *indexData) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("cannot convert search index to JSON: %v", err)
+	}
+
+	if err := os.WriteFile(i.FilePath, jsonData, 0644); err != nil {
+		return fmt.Errorf("cannot write search index: %v", err)
+	}
+
+	return nil
+}
+
+// Replaces the documents of the file in the index. A missing index is left missing, to be rebuilt by the next search
+func (i *Index) Update(filePath string, documents []Document) error {
+	data, exists := i.load()
+	if !exists {
+		return i.Invalidate()
+	}
+
+	for _, document := range data.Files[filePath] {
+		removePostings(data, &document)
+	}
+	delete(data.Files, filePath)
+
+	if len(documents) > 0 {
+		data.Files[filePath] = documents
+		for _, document := range documents {
+			addPostings(data, &document)
+		}
+	}
+
+	return i.save(data)
+}
+
+// Removes the index so it is rebuilt by the next search, e.g. after indexed files were deleted
+func (i *Index) Invalidate() error {
+	if err := os.Remove(i.FilePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("cannot remove search index: %v", err)
+	}
+
+	return nil
+}
+
+// Rebuilds the index from the documents of every indexed file
+func (i *Index) Rebuild(documentsByFile map[string][]Document) error {
+	data := &indexData{Version: indexVersion, Files: map[string][]Document{}, Postings: map[string][]string{}}
+	for filePath, documents := range documentsByFile {
+		if len(documents) == 0 {
+			continue
+		}
+
+		data.Files[filePath] = documents
+		for _, document := range documents {
+			addPostings(data, &document)
+		}
+	}
+
+	return i.save(data)
+}
+
+func addPostings(data *indexData, document *Document) {
+	key := document.key()
+	for term := range document.Terms {
+		data.Postings[term] = append(data.Postings[term], key)
+	}
+}
+
+func removePostings(data *indexData, document *Document) {
+	key := document.key()
+	for term := range document.Terms {
+		keys := data.Postings[term]
+		for j, postingKey := range keys {
+			if postingKey == key {
+				keys = append(keys[:j], keys[j+1:]...)
+				break
+			}
+		}
+
+		if len(keys) == 0 {
+			delete(data.Postings, term)
+		} else {
+			data.Postings[term] = keys
+		}
+	}
+}
+
+// Searches the documents containing every term of the query, exactly, as a prefix or with a typo,
+// ranked by relevance. A missing index is rebuilt first from the documents returned by collect
+func (i *Index) Search(query string, limit int, collect func() (map[string][]Document, error)) ([]Result, error) {
+	queryTerms := Tokenize(query)
+	if len(queryTerms) == 0 {
+		return nil, fmt.Errorf("query must contain at least one word")
+	}
+
+	data, exists := i.load()
+	if !exists {
+		documentsByFile, err := collect()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := i.Rebuild(documentsByFile); err != nil {
+			return nil, err
+		}
+
+		if data, exists = i.load(); !exists {
+			return nil, fmt.Errorf("cannot load search index")
+		}
+	}
+
+	documents := map[string]*Document{}
+	for _, fileDocuments := range data.Files {
+		for j := range fileDocuments {
+			documents[fileDocuments[j].key()] = &fileDocuments[j]
+		}
+	}
+
+	scores := map[string]float64{}
+	for n, queryTerm := range queryTerms {
+		termScores := map[string]float64{}
+		for term, keys := range data.Postings {
+			weight := matchWeight(queryTerm, term)
+			if weight == 0 {
+				continue
+			}
+
+			idf := math.Log(1 + float64(len(documents))/float64(len(keys)))
+			for _, key := range keys {
+				frequency := float64(documents[key].Terms[term])
+				termScores[key] = max(termScores[key], weight*(1+math.Log(frequency))*idf)
+			}
+		}
+
+		// Every term of the query must match, so documents missing this one are dropped
+		for key := range scores {
+			if _, matches := termScores[key]; !matches {
+				delete(scores, key)
+			}
+		}
+		for key, score := range termScores {
+			if _, matches := scores[key]; matches || n == 0 {
+				scores[key] += score
+			}
+		}
+	}
+
+	results := []Result{}
+	for key, score := range scores {
+		document := documents[key]
+		results = append(results, Result{Kind: document.Kind, ProjectId: document.ProjectId, Id: document.Id, Name: document.Name, Score: math.Round(score*100) / 100})
+	}
+
+	sort.Slice(results, func(a, b int) bool {
+		if results[a].Score != results[b].Score {
+			return results[a].Score > results[b].Score
+		}
+		if results[a].ProjectId != results[b].ProjectId {
+			return results[a].ProjectId < results[b].ProjectId
+		}
+		if results[a].Kind != results[b].Kind {
+			return results[a].Kind == KIND_PROJECT
+		}
+		return results[a].Id < results[b].Id
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
+}
+
+// Returns how well the term of a document matches the term of the query, or 0 if it does not match
+func matchWeight(queryTerm, term string) float64 {
+	switch {
+	case term == queryTerm:
+		return exactWeight
+	case strings.HasPrefix(term, queryTerm):
+		return prefixWeight
+	}
+
+	maxDistance := 0
+	if length := len([]rune(queryTerm)); length >= 8 {
+		maxDistance = 2
+	} else if length >= 4 {
+		maxDistance = 1
+	}
+
+	if maxDistance > 0 && editDistance(queryTerm, term, maxDistance) <= maxDistance {
+		return fuzzyWeight
+	}
+
+	return 0
+}
+
+// Returns the optimal string alignment distance between the words, where swapping two adjacent letters counts as one edit,
+// or a distance above maxDistance as soon as it is known to exceed it
+func editDistance(a, b string, maxDistance int) int {
+	ra, rb := []rune(a), []rune(b)
+	if abs(len(ra)-len(rb)) > maxDistance {
+		return maxDistance + 1
+	}
+
+	beforePrevious := make([]int, len(rb)+1)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	previousRowMinimum := 0
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		rowMinimum := current[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				current[j] = min(current[j], beforePrevious[j-2]+1)
+			}
+			rowMinimum = min(rowMinimum, current[j])
+		}
+
+		// A transposition reaches back two rows, so both rows must exceed the distance
+		if rowMinimum > maxDistance && previousRowMinimum >= maxDistance {
+			return maxDistance + 1
+		}
+		beforePrevious, previous, current = previous, current, beforePrevious
+		previousRowMinimum = rowMinimum
+	}
+
+	return previous[len(rb)]
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+
+	return value
+}
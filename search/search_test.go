@@ -0,0 +1,229 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEditDistanceCountsSwappedLettersAsOneEdit(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		want int
+	}{
+		{"auth", "auth", 0},
+		{"auht", "auth", 1},
+		{"uath", "auth", 1},
+		{"atuh", "auth", 1},
+		{"autho", "auth", 1},
+		{"aut", "auth", 1},
+		{"aurh", "auth", 1},
+		{"uahtentication", "authentication", 2},
+		{"abcd", "badc", 2},
+		{"ca", "abc", 3},
+	} {
+		if got := editDistance(test.a, test.b, 3); got != test.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestEditDistanceExceedsTheMaximum(t *testing.T) {
+	for _, test := range []struct {
+		a, b        string
+		maxDistance int
+	}{
+		{"auth", "login", 1},
+		{"auth", "authentication", 2},
+		{"abcd", "badc", 1},
+	} {
+		if got := editDistance(test.a, test.b, test.maxDistance); got <= test.maxDistance {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want more than %d", test.a, test.b, test.maxDistance, got, test.maxDistance)
+		}
+	}
+}
+
+func TestMatchWeightFindsSwappedLetters(t *testing.T) {
+	for _, test := range []struct {
+		queryTerm, term string
+		want            float64
+	}{
+		{"auth", "auth", exactWeight},
+		{"auth", "authentication", prefixWeight},
+		{"auht", "auth", fuzzyWeight},
+		{"recieve", "receive", fuzzyWeight},
+		{"aiu", "aui", 0}, // Too short for a typo
+		{"auht", "login", 0},
+	} {
+		if got := matchWeight(test.queryTerm, test.term); got != test.want {
+			t.Errorf("matchWeight(%q, %q) = %v, want %v", test.queryTerm, test.term, got, test.want)
+		}
+	}
+}
+
+func newTestDocument(kind string, projectId int, id int, name string) Document {
+	document := NewDocument(kind, projectId, id, name)
+	document.AddText(name, 1)
+
+	return *document
+}
+
+// Returns the keys of the results in their order, e.g. "task:1:2"
+func resultKeys(results []Result) []string {
+	keys := []string{}
+	for _, result := range results {
+		keys = append(keys, fmt.Sprintf("%s:%d:%d", result.Kind, result.ProjectId, result.Id))
+	}
+
+	return keys
+}
+
+// Returns the documents of a project file and of its tasks file
+func testDocuments() map[string][]Document {
+	return map[string][]Document{
+		"projects.json": {newTestDocument(KIND_PROJECT, 1, 1, "Login service")},
+		"tasks-1.json": {
+			newTestDocument(KIND_TASK, 1, 1, "Fix auth flow"),
+			newTestDocument(KIND_TASK, 1, 2, "Write authentication docs"),
+			newTestDocument(KIND_TASK, 1, 3, "Deploy the website"),
+		},
+	}
+}
+
+func TestSearchRanksMatches(t *testing.T) {
+	index := NewIndex(filepath.Join(t.TempDir(), "search-index.json"))
+	collect := func() (map[string][]Document, error) { return testDocuments(), nil }
+
+	for _, test := range []struct {
+		query string
+		want  string
+	}{
+		{"auth", "task:1:1,task:1:2"},
+		{"authentication", "task:1:2"},
+		{"authentcation", "task:1:2"},
+		{"auht", "task:1:1"},
+		{"service", "project:1:1"},
+		{"auth docs", "task:1:2"},
+		{"auth website", ""},
+		{"webiste deploy", "task:1:3"},
+	} {
+		results, err := index.Search(test.query, 0, collect)
+		if err != nil {
+			t.Fatalf("Search(%q): %v", test.query, err)
+		}
+
+		if got := strings.Join(resultKeys(results), ","); got != test.want {
+			t.Errorf("Search(%q) = %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestSearchLimitsTheResults(t *testing.T) {
+	index := NewIndex(filepath.Join(t.TempDir(), "search-index.json"))
+	collect := func() (map[string][]Document, error) { return testDocuments(), nil }
+
+	results, err := index.Search("auth", 1, collect)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(resultKeys(results), ","); got != "task:1:1" {
+		t.Errorf("Search(\"auth\", 1) = %q, want the best result", got)
+	}
+}
+
+func TestUpdateReplacesTheDocumentsOfTheFile(t *testing.T) {
+	index := NewIndex(filepath.Join(t.TempDir(), "search-index.json"))
+	if err := index.Rebuild(testDocuments()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := index.Update("tasks-1.json", []Document{newTestDocument(KIND_TASK, 1, 4, "Review login page")}); err != nil {
+		t.Fatal(err)
+	}
+
+	collect := func() (map[string][]Document, error) {
+		t.Error("the index was rebuilt after an update")
+		return nil, nil
+	}
+
+	for _, test := range []struct {
+		query string
+		want  string
+	}{
+		{"login", "project:1:1,task:1:4"},
+		{"review", "task:1:4"},
+		{"auth", ""},
+	} {
+		results, err := index.Search(test.query, 0, collect)
+		if err != nil {
+			t.Fatalf("Search(%q): %v", test.query, err)
+		}
+
+		if got := strings.Join(resultKeys(results), ","); got != test.want {
+			t.Errorf("Search(%q) = %q, want %q", test.query, got, test.want)
+		}
+	}
+
+	if err := index.Update("tasks-1.json", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := index.Search("review", 0, collect)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 0 {
+		t.Errorf("Search(\"review\") = %q after the file was emptied, want no results", resultKeys(results))
+	}
+}
+
+func TestMissingIndexIsRebuiltOnSearch(t *testing.T) {
+	index := NewIndex(filepath.Join(t.TempDir(), "search-index.json"))
+	nbOfCollects := 0
+	collect := func() (map[string][]Document, error) {
+		nbOfCollects++
+		return testDocuments(), nil
+	}
+
+	// An update leaves a missing index missing
+	if err := index.Update("tasks-1.json", []Document{newTestDocument(KIND_TASK, 1, 4, "Review login page")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(index.FilePath); !os.IsNotExist(err) {
+		t.Errorf("Update created the missing index")
+	}
+
+	for _, step := range []struct {
+		invalidate bool
+		want       int
+	}{
+		{false, 1},
+		{false, 1},
+		{true, 2},
+	} {
+		if step.invalidate {
+			if err := index.Invalidate(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := index.Search("auth", 0, collect); err != nil {
+			t.Fatal(err)
+		}
+
+		if nbOfCollects != step.want {
+			t.Errorf("index rebuilt %d time(s), want %d", nbOfCollects, step.want)
+		}
+	}
+
+	if err := index.Invalidate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Invalidate(); err != nil {
+		t.Errorf("Invalidate of a missing index: %v", err)
+	}
+}
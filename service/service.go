@@ -2,21 +2,29 @@ package service
 
 import (
 	"github.com/MuradIsayev/todo-tracker/project"
+	"github.com/MuradIsayev/todo-tracker/session"
 	"github.com/MuradIsayev/todo-tracker/task"
 )
 
 type Manager struct {
 	TaskService    task.TaskManager
 	ProjectService project.ProjectManager
+	SessionService session.SessionManager
 }
 
-func NewManager(taskService task.TaskManager, projectService project.ProjectManager) *Manager {
+func NewManager(taskService task.TaskManager, projectService project.ProjectManager, sessionService session.SessionManager) *Manager {
 	return &Manager{
 		TaskService:    taskService,
 		ProjectService: projectService,
+		SessionService: sessionService,
 	}
 }
 
+// Returns a manager updating the tasks through the task service, e.g. one bound to another project
+func (m *Manager) WithTaskService(taskService task.TaskManager) *Manager {
+	return NewManager(taskService, m.ProjectService, m.SessionService)
+}
+
 func (m *Manager) DeleteProjectAndCorrespondingTasks(projectId string) error {
 	if err := m.TaskService.DeleteTasksByProjectId(projectId); err != nil {
 		return err
@@ -33,10 +41,15 @@ func (m *Manager) DeleteAllProjectsWithAllTasks(projectId string, shouldAlterTas
 	return m.ProjectService.DeleteAllProjects()
 }
 
-func (m *Manager) UpdateTaskAndProjectTimers(taskId, projectId int, newDuration int) error {
+// Adds the focused time to the task and its project, and records it as sessions over the segments of the timer
+func (m *Manager) UpdateTaskAndProjectTimers(taskId, projectId int, newDuration int, segments []session.Segment) error {
 	if err := m.TaskService.UpdateTaskTimer(taskId, newDuration); err != nil {
 		return err
 	}
 
-	return m.ProjectService.UpdateProjectTimer(projectId, newDuration)
+	if err := m.ProjectService.UpdateProjectTimer(projectId, newDuration); err != nil {
+		return err
+	}
+
+	return m.SessionService.RecordSession(taskId, projectId, newDuration, segments)
 }
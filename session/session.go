@@ -0,0 +1,155 @@
+package session
+
+import (
+	"math"
+	"time"
+
+	"github.com/MuradIsayev/todo-tracker/base"
+	"github.com/MuradIsayev/todo-tracker/clock"
+)
+
+// Session is a recorded interval of focused time spent on a task
+type Session struct {
+	Id        int       `json:"id"`
+	TaskId    int       `json:"taskId"`
+	ProjectId int       `json:"projectId"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+	Duration  int       `json:"duration"`
+}
+
+// Segment is an interval of focused time, e.g. between the start of a timer and its first pause
+type Segment struct {
+	StartedAt time.Time
+	EndedAt   time.Time
+}
+
+type SessionService struct {
+	baseService *base.BaseService[Session]
+	clock       clock.Clock
+}
+
+func NewSessionService(filePath string, clock clock.Clock) *SessionService {
+	return &SessionService{
+		clock: clock,
+		baseService: &base.BaseService[Session]{
+			FilePath: filePath,
+			Clock:    clock,
+		},
+	}
+}
+
+type SessionManager interface {
+	RecordSession(taskId, projectId int, duration int, segments []Segment) error
+}
+
+// Records the focused time (in seconds) as one session per segment of the timer, so the pauses are left out.
+// The duration is spread over the segments by their length, as it can be shorter than their wall-clock time
+// (e.g. a discarded idle interval). Without segments, a single session ending now is recorded
+func (s *SessionService) RecordSession(taskId, projectId int, duration int, segments []Segment) error {
+	if duration <= 0 {
+		return nil
+	}
+
+	focusedSegments := []Segment{}
+	var focusedTime time.Duration
+	for _, segment := range segments {
+		if segment.EndedAt.After(segment.StartedAt) {
+			focusedSegments = append(focusedSegments, segment)
+			focusedTime += segment.EndedAt.Sub(segment.StartedAt)
+		}
+	}
+
+	if len(focusedSegments) == 0 {
+		endedAt := s.clock.Now()
+		focusedSegments = []Segment{{StartedAt: endedAt.Add(-time.Duration(duration) * time.Second), EndedAt: endedAt}}
+		focusedTime = time.Duration(duration) * time.Second
+	}
+
+	sessions := []Session{}
+	if err := s.baseService.ReadFromFile(&sessions); err != nil {
+		return err
+	}
+
+	remainingSeconds := duration
+	for i, segment := range focusedSegments {
+		// The last segment gets the rounding remainder
+		segmentSeconds := remainingSeconds
+		if i < len(focusedSegments)-1 {
+			segmentSeconds = min(int(math.Round(float64(duration)*float64(segment.EndedAt.Sub(segment.StartedAt))/float64(focusedTime))), remainingSeconds)
+		}
+		remainingSeconds -= segmentSeconds
+
+		if segmentSeconds <= 0 {
+			continue
+		}
+
+		sessions = append(sessions, Session{
+			Id:        s.baseService.GetNextID(sessions),
+			TaskId:    taskId,
+			ProjectId: projectId,
+			StartedAt: segment.StartedAt,
+			EndedAt:   segment.EndedAt,
+			Duration:  segmentSeconds,
+		})
+	}
+
+	return s.baseService.WriteToFile(sessions)
+}
+
+// Returns the seconds of the session that fall within [from, to). The focused time is assumed
+// to be spread evenly between the start and the end of the session
+func (session *Session) DurationWithin(from, to time.Time) int {
+	start, end := session.StartedAt, session.EndedAt
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+
+	if !end.After(start) {
+		return 0
+	}
+
+	span := session.EndedAt.Sub(session.StartedAt)
+	if span <= 0 {
+		return session.Duration
+	}
+
+	return int(float64(session.Duration) * float64(end.Sub(start)) / float64(span))
+}
+
+// Finds the sessions that overlap [from, to)
+func (s *SessionService) FindSessions(from, to time.Time) ([]Session, error) {
+	sessions := []Session{}
+	if err := s.baseService.ReadFromFile(&sessions); err != nil {
+		return nil, err
+	}
+
+	var sessionsInRange []Session
+	for _, session := range sessions {
+		if session.EndedAt.After(from) && session.StartedAt.Before(to) {
+			sessionsInRange = append(sessionsInRange, session)
+		}
+	}
+
+	return sessionsInRange, nil
+}
+
+// Sums the focused seconds within [from, to). A projectId of 0 matches all projects
+func (s *SessionService) SumFocusedTime(from, to time.Time, projectId int) (int, error) {
+	sessions, err := s.FindSessions(from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	focusedSeconds := 0
+	for _, session := range sessions {
+		if projectId == 0 || session.ProjectId == projectId {
+			focusedSeconds += session.DurationWithin(from, to)
+		}
+	}
+
+	return focusedSeconds, nil
+}
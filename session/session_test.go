@@ -0,0 +1,72 @@
+package session
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MuradIsayev/todo-tracker/clock"
+)
+
+func TestRecordSessionSplitsAtTheSegments(t *testing.T) {
+	start := time.Date(2026, 10, 19, 23, 50, 0, 0, time.UTC)
+	sessionService := NewSessionService(filepath.Join(t.TempDir(), "sessions.json"), clock.NewFakeClock(start.Add(3*time.Hour)))
+
+	// 10 minutes before midnight, a pause of 2 hours, then 20 minutes after it. 5 minutes of idle time were discarded
+	segments := []Segment{
+		{StartedAt: start, EndedAt: start.Add(10 * time.Minute)},
+		{StartedAt: start.Add(130 * time.Minute), EndedAt: start.Add(150 * time.Minute)},
+	}
+	if err := sessionService.RecordSession(7, 1, 25*60, segments); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := sessionService.FindSessions(time.Time{}, start.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sessions) != 2 {
+		t.Fatalf("recorded %d sessions, want one per segment", len(sessions))
+	}
+
+	for i, want := range []struct {
+		startedAt time.Time
+		duration  int
+	}{
+		{start, 500},
+		{start.Add(130 * time.Minute), 1000},
+	} {
+		if !sessions[i].StartedAt.Equal(want.startedAt) || sessions[i].Duration != want.duration {
+			t.Errorf("session %d started at %s with %d seconds, want %s with %d", i, sessions[i].StartedAt, sessions[i].Duration, want.startedAt, want.duration)
+		}
+	}
+
+	midnight := time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)
+	focusedSeconds, err := sessionService.SumFocusedTime(midnight, midnight.Add(24*time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if focusedSeconds != 1000 {
+		t.Errorf("focused time after midnight = %d, want only the second segment", focusedSeconds)
+	}
+}
+
+func TestRecordSessionWithoutSegmentsEndsNow(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	sessionService := NewSessionService(filepath.Join(t.TempDir(), "sessions.json"), clock.NewFakeClock(now))
+
+	if err := sessionService.RecordSession(7, 1, 90, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := sessionService.FindSessions(time.Time{}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sessions) != 1 || !sessions[0].EndedAt.Equal(now) || !sessions[0].StartedAt.Equal(now.Add(-90*time.Second)) {
+		t.Errorf("sessions = %+v, want one session of 90 seconds ending now", sessions)
+	}
+}
@@ -0,0 +1,206 @@
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"github.com/MuradIsayev/todo-tracker/clock"
+	"github.com/MuradIsayev/todo-tracker/constants"
+	"github.com/MuradIsayev/todo-tracker/helpers"
+	"github.com/MuradIsayev/todo-tracker/project"
+	"github.com/MuradIsayev/todo-tracker/session"
+	"github.com/MuradIsayev/todo-tracker/task"
+)
+
+// Number of projects listed as most worked
+const nbOfTopProjects = 5
+
+// PeriodStats counts the tasks and the focused time of a period
+type PeriodStats struct {
+	Label          string    `json:"period"`
+	Start          time.Time `json:"-"`
+	CreatedTasks   int       `json:"createdTasks"`
+	CompletedTasks int       `json:"completedTasks"`
+	FocusedSeconds int       `json:"focusedSeconds"`
+}
+
+// ProjectStats is the total focused time of a project
+type ProjectStats struct {
+	Name           string `json:"project"`
+	OpenTasks      int    `json:"openTasks"`
+	TotalTasks     int    `json:"totalTasks"`
+	FocusedSeconds int    `json:"focusedSeconds"`
+}
+
+// Stats summarises all projects, tasks and sessions of the tracker
+type Stats struct {
+	Periods              []PeriodStats  `json:"periods"`
+	CurrentStreak        int            `json:"currentStreakDays"`
+	LongestStreak        int            `json:"longestStreakDays"`
+	TopProjects          []ProjectStats `json:"topProjects"`
+	NbOfSessions         int            `json:"sessions"`
+	AverageSessionLength int            `json:"averageSessionSeconds"`
+	// Focused seconds per day, keyed by the day in DAY_FORMAT
+	FocusedSecondsByDay map[string]int `json:"focusedSecondsByDay"`
+}
+
+type StatsService struct {
+	projectService *project.ProjectService
+	taskService    *task.TaskService
+	sessionService *session.SessionService
+	clock          clock.Clock
+}
+
+func NewStatsService(projectService *project.ProjectService, taskService *task.TaskService, sessionService *session.SessionService, clock clock.Clock) *StatsService {
+	return &StatsService{
+		projectService: projectService,
+		taskService:    taskService,
+		sessionService: sessionService,
+		clock:          clock,
+	}
+}
+
+// Builds the stats of all projects, tasks and sessions
+func (s *StatsService) BuildStats() (*Stats, error) {
+	now := s.clock.Now()
+	today := helpers.StartOfDay(now)
+
+	projects, err := s.projectService.FindProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	tasksByProject, err := s.taskService.FindTasksOfAllProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessionService.FindSessions(time.Time{}, now)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &Stats{
+		Periods: []PeriodStats{
+			{Label: "Today", Start: today},
+			{Label: "This week", Start: helpers.StartOfWeek(now)},
+			{Label: "This month", Start: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())},
+			{Label: "All time"},
+		},
+		TopProjects:         []ProjectStats{},
+		FocusedSecondsByDay: map[string]int{},
+	}
+
+	for _, tasks := range tasksByProject {
+		for _, task := range tasks {
+			completedAt, isCompleted := task.CompletedAt()
+
+			for i := range stats.Periods {
+				period := &stats.Periods[i]
+				if !task.CreatedAt.Before(period.Start) {
+					period.CreatedTasks++
+				}
+				if isCompleted && !completedAt.Before(period.Start) {
+					period.CompletedTasks++
+				}
+			}
+		}
+	}
+
+	focusedSecondsByProject := map[int]int{}
+	totalSessionSeconds := 0
+	for _, session := range sessions {
+		stats.NbOfSessions++
+		totalSessionSeconds += session.Duration
+		focusedSecondsByProject[session.ProjectId] += session.Duration
+
+		for i := range stats.Periods {
+			stats.Periods[i].FocusedSeconds += session.DurationWithin(stats.Periods[i].Start, now)
+		}
+
+		// A session spanning midnight counts towards both days
+		for day := helpers.StartOfDay(session.StartedAt); day.Before(session.EndedAt); day = day.AddDate(0, 0, 1) {
+			stats.FocusedSecondsByDay[day.Format(constants.DAY_FORMAT)] += session.DurationWithin(day, day.AddDate(0, 0, 1))
+		}
+	}
+
+	if stats.NbOfSessions > 0 {
+		stats.AverageSessionLength = totalSessionSeconds / stats.NbOfSessions
+	}
+
+	stats.CurrentStreak, stats.LongestStreak = countStreaks(stats.FocusedSecondsByDay, today)
+
+	for _, project := range projects {
+		projectStats := ProjectStats{Name: project.Name, FocusedSeconds: project.TotalSpentTime}
+
+		// Time tracked before sessions were recorded only shows in the total of the project
+		projectStats.FocusedSeconds = max(projectStats.FocusedSeconds, focusedSecondsByProject[project.Id])
+
+		for _, task := range tasksByProject[project.Id] {
+			projectStats.TotalTasks++
+			if _, isCompleted := task.CompletedAt(); !isCompleted {
+				projectStats.OpenTasks++
+			}
+		}
+
+		if projectStats.FocusedSeconds > 0 {
+			stats.TopProjects = append(stats.TopProjects, projectStats)
+		}
+	}
+
+	sort.SliceStable(stats.TopProjects, func(i, j int) bool {
+		return stats.TopProjects[i].FocusedSeconds > stats.TopProjects[j].FocusedSeconds
+	})
+
+	if len(stats.TopProjects) > nbOfTopProjects {
+		stats.TopProjects = stats.TopProjects[:nbOfTopProjects]
+	}
+
+	return stats, nil
+}
+
+// Counts the consecutive days with focused time up to today, and the longest run of such days.
+// The current streak is kept until the end of today, so a day without focused time yet does not break it
+func countStreaks(focusedSecondsByDay map[string]int, today time.Time) (int, int) {
+	hasFocusedTime := func(day time.Time) bool {
+		return focusedSecondsByDay[day.Format(constants.DAY_FORMAT)] > 0
+	}
+
+	currentStreak := 0
+	day := today
+	if !hasFocusedTime(day) {
+		day = day.AddDate(0, 0, -1)
+	}
+	for hasFocusedTime(day) {
+		currentStreak++
+		day = day.AddDate(0, 0, -1)
+	}
+
+	days := []string{}
+	for day, seconds := range focusedSecondsByDay {
+		if seconds > 0 {
+			days = append(days, day)
+		}
+	}
+	sort.Strings(days)
+
+	longestStreak, streak := 0, 0
+	var previousDay time.Time
+	for _, dayText := range days {
+		day, err := time.ParseInLocation(constants.DAY_FORMAT, dayText, today.Location())
+		if err != nil {
+			continue
+		}
+
+		if streak > 0 && previousDay.AddDate(0, 0, 1).Equal(day) {
+			streak++
+		} else {
+			streak = 1
+		}
+
+		longestStreak = max(longestStreak, streak)
+		previousDay = day
+	}
+
+	return currentStreak, longestStreak
+}
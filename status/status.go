@@ -1,5 +1,10 @@
 package status
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Describes the status of an item
 type ItemStatus int
 
@@ -22,3 +27,17 @@ func (itemStatus ItemStatus) String() string {
 		return "UNKNOWN"
 	}
 }
+
+// Parses the status from its name, case-insensitively and with "-" accepted in place of "_"
+func ParseStatus(value string) (ItemStatus, error) {
+	switch strings.ReplaceAll(strings.ToUpper(value), "-", "_") {
+	case "TODO":
+		return TODO, nil
+	case "IN_PROGRESS":
+		return IN_PROGRESS, nil
+	case "DONE":
+		return DONE, nil
+	default:
+		return TODO, fmt.Errorf("invalid status %q, expected todo, in-progress or done", value)
+	}
+}
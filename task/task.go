@@ -2,39 +2,173 @@ package task
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/MuradIsayev/todo-tracker/base"
+	"github.com/MuradIsayev/todo-tracker/clock"
 	"github.com/MuradIsayev/todo-tracker/constants"
 	"github.com/MuradIsayev/todo-tracker/helpers"
 	"github.com/MuradIsayev/todo-tracker/project"
+	"github.com/MuradIsayev/todo-tracker/search"
 	"github.com/MuradIsayev/todo-tracker/status"
-	"github.com/olekukonko/tablewriter"
 )
 
 type Task struct {
 	Id             int               `json:"id"`
 	Name           string            `json:"name"`
+	Description    string            `json:"description,omitempty"`
+	Notes          []Note            `json:"notes,omitempty"`
 	Status         status.ItemStatus `json:"status"`
 	CreatedAt      time.Time         `json:"createdAt"`
 	UpdatedAt      time.Time         `json:"updatedAt"`
 	TotalSpentTime int               `json:"totalSpentTime"`
 	ProjectId      int               `json:"projectId"`
+	Tags           []string          `json:"tags,omitempty"`
+	DueDate        *time.Time        `json:"dueDate,omitempty"`
+	StatusHistory  []StatusChange    `json:"statusHistory,omitempty"`
+}
+
+// Moves the task to the status and records the change in its status history. Returns false if the task already has the status
+func (t *Task) setStatus(taskStatus status.ItemStatus, now time.Time) bool {
+	if t.Status == taskStatus {
+		return false
+	}
+
+	t.StatusHistory = append(t.StatusHistory, StatusChange{Status: taskStatus, ChangedAt: now})
+	t.Status = taskStatus
+	t.UpdatedAt = now
+
+	return true
+}
+
+// StatusChange records the status a task was moved to and when
+type StatusChange struct {
+	Status    status.ItemStatus `json:"status"`
+	ChangedAt time.Time         `json:"changedAt"`
+}
+
+// Note is a remark added to a task
+type Note struct {
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Returns the searchable text of the task
+func (t Task) SearchDocument() search.Document {
+	document := search.NewDocument(search.KIND_TASK, t.ProjectId, t.Id, t.Name)
+	document.AddText(t.Name, 3)
+	document.AddText(strings.Join(t.Tags, " "), 2)
+	document.AddText(t.Description, 2)
+	for _, note := range t.Notes {
+		document.AddText(note.Text, 1)
+	}
+
+	return *document
+}
+
+// Returns the status of the task at the given time, or false if the task did not exist yet.
+// Tasks without a status history fall back to their current status since UpdatedAt
+func (t *Task) StatusAt(at time.Time) (status.ItemStatus, bool) {
+	if at.Before(t.CreatedAt) {
+		return status.TODO, false
+	}
+
+	if len(t.StatusHistory) == 0 {
+		if at.Before(t.UpdatedAt) {
+			return status.TODO, true
+		}
+
+		return t.Status, true
+	}
+
+	itemStatus := status.TODO
+	for _, change := range t.StatusHistory {
+		if change.ChangedAt.After(at) {
+			break
+		}
+
+		itemStatus = change.Status
+	}
+
+	return itemStatus, true
+}
+
+// Returns the time the task was last marked as done, or false if the task is not done
+func (t *Task) CompletedAt() (time.Time, bool) {
+	if t.Status != status.DONE {
+		return time.Time{}, false
+	}
+
+	for i := len(t.StatusHistory) - 1; i >= 0; i-- {
+		if t.StatusHistory[i].Status == status.DONE {
+			return t.StatusHistory[i].ChangedAt, true
+		}
+	}
+
+	return t.UpdatedAt, true
+}
+
+// TaskRecord is the machine-readable representation of a task
+type TaskRecord struct {
+	Id                int       `json:"id"`
+	ProjectId         int       `json:"projectId"`
+	Name              string    `json:"name"`
+	Description       string    `json:"description"`
+	Notes             []string  `json:"notes"`
+	Status            string    `json:"status"`
+	Tags              []string  `json:"tags"`
+	DueDate           string    `json:"dueDate"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+	TotalSpentSeconds int       `json:"totalSpentSeconds"`
+}
+
+func NewTaskRecord(task Task) TaskRecord {
+	tags := task.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	var dueDate string
+	if task.DueDate != nil {
+		dueDate = task.DueDate.Format(constants.DAY_FORMAT)
+	}
+
+	notes := []string{}
+	for _, note := range task.Notes {
+		notes = append(notes, note.Text)
+	}
+
+	return TaskRecord{
+		Id:                task.Id,
+		ProjectId:         task.ProjectId,
+		Name:              task.Name,
+		Description:       task.Description,
+		Notes:             notes,
+		Status:            task.Status.String(),
+		Tags:              tags,
+		DueDate:           dueDate,
+		CreatedAt:         task.CreatedAt,
+		UpdatedAt:         task.UpdatedAt,
+		TotalSpentSeconds: task.TotalSpentTime,
+	}
 }
 
 type TaskService struct {
 	baseService    *base.BaseService[Task]
-	table          *tablewriter.Table
 	projectService *project.ProjectService
+	clock          clock.Clock
+	searchIndex    *search.Index
 }
 
-func NewTaskService(projectService *project.ProjectService, table *tablewriter.Table) *TaskService {
-	table.SetHeader([]string{constants.COLUMN_ID, constants.COLUMN_NAME, constants.COLUMN_STATUS, constants.COLUMN_CREATE_DATE, constants.COLUMN_UPDATE_DATE, constants.COLUMN_TOTAL_SPENT_TIME, constants.COLUMN_EMPTY})
-
+func NewTaskService(projectService *project.ProjectService, clock clock.Clock, searchIndex *search.Index) *TaskService {
 	return &TaskService{
-		table:          table,
 		projectService: projectService,
+		clock:          clock,
+		searchIndex:    searchIndex,
 	}
 }
 
@@ -49,19 +183,28 @@ func (s *TaskService) DeleteAllTasks(projectId string, shouldAlterTasksCounter b
 		return err
 	}
 
+	if s.searchIndex != nil {
+		if err := s.searchIndex.Invalidate(); err != nil {
+			return err
+		}
+	}
+
 	if shouldAlterTasksCounter {
 		if err := s.projectService.UpdateTotalTasksOfProject(projectId, 0); err != nil {
 			return err
 		}
 	}
 
-	fmt.Println("Tasks deleted successfully")
-
 	return nil
 }
 
+// Returns the path of the file storing the tasks of the project
+func taskFilePath(projectId string) string {
+	return fmt.Sprintf("output/tasks/%s_%s", projectId, constants.TASK_FILE_NAME)
+}
+
 func (t *TaskService) DeleteTasksByProjectId(projectId string) error {
-	filePathOfTask := fmt.Sprintf("output/tasks/%s_%s", projectId, constants.TASK_FILE_NAME)
+	filePathOfTask := taskFilePath(projectId)
 
 	if fileExists := helpers.DoesFileExist(filePathOfTask); !fileExists {
 		return nil
@@ -71,25 +214,57 @@ func (t *TaskService) DeleteTasksByProjectId(projectId string) error {
 		return err
 	}
 
-	fmt.Println("Tasks deleted successfully")
+	if t.searchIndex != nil {
+		return t.searchIndex.Update(filePathOfTask, nil)
+	}
 
 	return nil
 }
 
 func (t *TaskService) UpdateTaskTimer(taskId int, newDuration int) error {
-	return t.baseService.UpdateTotalSpentTime(taskId, newDuration)
+	return t.addSpentTime(taskId, newDuration)
+}
+
+// Adds the spent time to the task. A task still to do is moved to in progress, recording the change in its status history
+func (s *TaskService) addSpentTime(id int, spentTime int) error {
+	tasks := []Task{}
+	if err := s.baseService.ReadFromFile(&tasks); err != nil {
+		return err
+	}
+
+	index, task, err := s.baseService.FindItemById(tasks, id)
+	if err != nil {
+		return err
+	}
+
+	task.TotalSpentTime += spentTime
+	if task.Status == status.TODO {
+		task.setStatus(status.IN_PROGRESS, s.clock.Now())
+	}
+	tasks[index] = *task
+
+	return s.baseService.WriteToFile(tasks)
 }
 
 func (s *TaskService) AddProjectIdToTaskService(projectId string) *TaskService {
-	filePathOfTask := fmt.Sprintf("output/tasks/%s_%s", projectId, constants.TASK_FILE_NAME)
+	filePathOfTask := taskFilePath(projectId)
 
 	s.baseService = &base.BaseService[Task]{
 		FilePath: filePathOfTask,
+		Clock:    s.clock,
+		Index:    s.searchIndex,
 	}
 
 	return s
 }
 
+// Returns a copy of the service bound to the tasks of the project, this service stays bound to its own project
+func (s *TaskService) ForProject(projectId string) *TaskService {
+	projectTaskService := *s
+
+	return projectTaskService.AddProjectIdToTaskService(projectId)
+}
+
 func (s *TaskService) UpdateTaskSpentTime(id int, spentTime int) error {
 	// find task by id
 	task, err := s.FindTaskById(strconv.Itoa(id))
@@ -103,11 +278,7 @@ func (s *TaskService) UpdateTaskSpentTime(id int, spentTime int) error {
 	}
 
 	// update task total spent time
-	if err := s.baseService.UpdateTotalSpentTime(id, spentTime); err != nil {
-		return err
-	}
-
-	return nil
+	return s.addSpentTime(id, spentTime)
 }
 
 func (s *TaskService) FindTaskById(id string) (*Task, error) {
@@ -130,131 +301,375 @@ func (s *TaskService) FindTaskById(id string) (*Task, error) {
 	return task, nil
 }
 
-func (s *TaskService) UpdateTaskStatus(id string, taskStatus status.ItemStatus) error {
-	if err := s.baseService.UpdateItemStatus(id, taskStatus); err != nil {
-		return err
+// Finds all tasks of the project, independently of the project the service is bound to
+func (s *TaskService) FindTasksOfProject(projectId int) ([]Task, error) {
+	projectBaseService := &base.BaseService[Task]{
+		FilePath: taskFilePath(strconv.Itoa(projectId)),
+		Clock:    s.clock,
 	}
 
-	fmt.Println("Task status updated successfully")
+	tasks := []Task{}
+	if err := projectBaseService.ReadFromFile(&tasks); err != nil {
+		return nil, err
+	}
 
-	return nil
+	return tasks, nil
 }
 
-func (s *TaskService) UpdateTaskName(id, name string) error {
-	if err := s.baseService.UpdateItemName(id, name); err != nil {
+// Finds the tasks of all projects, keyed by project ID and task ID
+func (s *TaskService) FindTasksOfAllProjects() (map[int]map[int]Task, error) {
+	projects, err := s.projectService.FindProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	tasksByProject := map[int]map[int]Task{}
+	for _, project := range projects {
+		tasks, err := s.FindTasksOfProject(project.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		tasksByProject[project.Id] = map[int]Task{}
+		for _, task := range tasks {
+			tasksByProject[project.Id][task.Id] = task
+		}
+	}
+
+	return tasksByProject, nil
+}
+
+// ProjectTasks is a project with some of its tasks
+type ProjectTasks struct {
+	Project project.Project
+	Tasks   []Task
+}
+
+// Finds the tasks of all projects matching the condition, grouped by project.
+// Projects without a matching task are left out
+func (s *TaskService) FindMatchingTasks(match func(task Task) bool) ([]ProjectTasks, error) {
+	projects, err := s.projectService.FindProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []ProjectTasks{}
+	for _, project := range projects {
+		tasks, err := s.FindTasksOfProject(project.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		matchingTasks := []Task{}
+		for _, task := range tasks {
+			if match(task) {
+				matchingTasks = append(matchingTasks, task)
+			}
+		}
+
+		if len(matchingTasks) > 0 {
+			groups = append(groups, ProjectTasks{Project: project, Tasks: matchingTasks})
+		}
+	}
+
+	return groups, nil
+}
+
+// Adds the tags to the task, or removes them if remove is set
+func (s *TaskService) UpdateTaskTags(id string, tags []string, remove bool) error {
+	taskId, err := helpers.ValidateIdAndConvertToInt(id)
+	if err != nil {
+		return err
+	}
+
+	tasks := []Task{}
+	if err := s.baseService.ReadFromFile(&tasks); err != nil {
+		return err
+	}
+
+	index, task, err := s.baseService.FindItemById(tasks, taskId)
+	if err != nil {
 		return err
 	}
 
-	fmt.Println("Task name updated successfully")
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		position := slices.Index(task.Tags, tag)
 
-	return nil
+		if remove && position != -1 {
+			task.Tags = slices.Delete(task.Tags, position, position+1)
+		} else if !remove && position == -1 && tag != "" {
+			task.Tags = append(task.Tags, tag)
+		}
+	}
+
+	task.UpdatedAt = s.clock.Now()
+	tasks[index] = *task
+
+	return s.baseService.WriteToFile(tasks)
 }
 
-func (s *TaskService) DeleteTask(id string, projectId string) error {
-	if err := s.baseService.DeleteItemById(id); err != nil {
+// Sets the day the task is due, or clears it if the due date is nil
+func (s *TaskService) UpdateTaskDueDate(id string, dueDate *time.Time) error {
+	taskId, err := helpers.ValidateIdAndConvertToInt(id)
+	if err != nil {
 		return err
 	}
 
 	tasks := []Task{}
-	err := s.baseService.ReadFromFile(&tasks)
+	if err := s.baseService.ReadFromFile(&tasks); err != nil {
+		return err
+	}
+
+	index, task, err := s.baseService.FindItemById(tasks, taskId)
 	if err != nil {
 		return err
 	}
 
-	if err := s.projectService.UpdateTotalTasksOfProject(projectId, len(tasks)); err != nil {
+	task.DueDate = dueDate
+	task.UpdatedAt = s.clock.Now()
+	tasks[index] = *task
+
+	return s.baseService.WriteToFile(tasks)
+}
+
+// Updates the status of the task and records the change in its status history
+func (s *TaskService) UpdateTaskStatus(id string, taskStatus status.ItemStatus) error {
+	taskId, err := helpers.ValidateIdAndConvertToInt(id)
+	if err != nil {
 		return err
 	}
 
-	fmt.Println("Task deleted successfully")
+	tasks := []Task{}
+	if err := s.baseService.ReadFromFile(&tasks); err != nil {
+		return err
+	}
 
-	return nil
+	index, task, err := s.baseService.FindItemById(tasks, taskId)
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+	task.setStatus(taskStatus, now)
+	task.UpdatedAt = now
+	tasks[index] = *task
+
+	return s.baseService.WriteToFile(tasks)
 }
 
-func defineTableFooterText(nbOfLeftTasks, nbOfTotalTasks int) string {
-	if nbOfLeftTasks == 0 && nbOfTotalTasks == 0 {
-		return "No tasks found"
+// Sets the status of the tasks with the IDs in a single write, recording the changes in their status histories.
+// Nothing is changed if any of the tasks is not found. Returns the tasks whose status has changed
+func (s *TaskService) UpdateTasksStatus(ids []int, taskStatus status.ItemStatus) ([]Task, error) {
+	tasks := []Task{}
+	if err := s.baseService.ReadFromFile(&tasks); err != nil {
+		return nil, err
 	}
 
-	return fmt.Sprintf("Left tasks: %d", nbOfLeftTasks)
+	indexes, err := s.findTaskIndexes(tasks, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	changedTasks := []Task{}
+	for _, index := range indexes {
+		if tasks[index].setStatus(taskStatus, now) {
+			changedTasks = append(changedTasks, tasks[index])
+		}
+	}
+
+	if len(changedTasks) == 0 {
+		return changedTasks, nil
+	}
+
+	return changedTasks, s.baseService.WriteToFile(tasks)
+}
+
+// Deletes the tasks with the IDs in a single write. Nothing is deleted if any of the tasks is not found. Returns the deleted tasks
+func (s *TaskService) DeleteTasks(ids []int, projectId string) ([]Task, error) {
+	tasks := []Task{}
+	if err := s.baseService.ReadFromFile(&tasks); err != nil {
+		return nil, err
+	}
+
+	indexes, err := s.findTaskIndexes(tasks, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := map[int]bool{}
+	for _, index := range indexes {
+		deleted[index] = true
+	}
+
+	deletedTasks := []Task{}
+	remainingTasks := []Task{}
+	for index, task := range tasks {
+		if deleted[index] {
+			deletedTasks = append(deletedTasks, task)
+		} else {
+			remainingTasks = append(remainingTasks, task)
+		}
+	}
+
+	if err := s.baseService.WriteToFile(remainingTasks); err != nil {
+		return nil, err
+	}
+
+	if err := s.projectService.UpdateTotalTasksOfProject(projectId, len(remainingTasks)); err != nil {
+		return nil, err
+	}
+
+	return deletedTasks, nil
+}
+
+// Returns the indexes of the tasks with the IDs, or an error naming the first ID that is not found
+func (s *TaskService) findTaskIndexes(tasks []Task, ids []int) ([]int, error) {
+	indexes := []int{}
+	for _, id := range ids {
+		index, _, err := s.baseService.FindItemById(tasks, id)
+		if err != nil {
+			return nil, fmt.Errorf("task with ID=%d not found, no task was changed", id)
+		}
+
+		indexes = append(indexes, index)
+	}
+
+	return indexes, nil
+}
+
+func (s *TaskService) UpdateTaskName(id, name string) error {
+	return s.baseService.UpdateItemName(id, name)
+}
+
+func (s *TaskService) UpdateTaskDescription(id, description string) error {
+	return s.baseService.UpdateItemDescription(id, description)
 }
 
-func (s *TaskService) ListTasks(statusFilter status.ItemStatus) error {
-	s.table.ClearRows()
-	s.table.ClearFooter()
+// Adds the note to the task
+func (s *TaskService) AddTaskNote(id string, text string) error {
+	taskId, err := helpers.ValidateIdAndConvertToInt(id)
+	if err != nil {
+		return err
+	}
 
 	tasks := []Task{}
-	err := s.baseService.ReadFromFile(&tasks)
+	if err := s.baseService.ReadFromFile(&tasks); err != nil {
+		return err
+	}
+
+	index, task, err := s.baseService.FindItemById(tasks, taskId)
 	if err != nil {
 		return err
 	}
 
-	var nbOfLeftTasks int
+	now := s.clock.Now()
+	task.Notes = append(task.Notes, Note{Text: text, CreatedAt: now})
+	task.UpdatedAt = now
+	tasks[index] = *task
 
-	for _, task := range tasks {
-		if statusFilter == -1 || task.Status == statusFilter {
-			formatSpendTime := helpers.FormatSpendTime(task.TotalSpentTime)
-			createdAt := task.CreatedAt.Format(constants.DATE_FORMAT)
-			updatedAt := task.UpdatedAt.Format(constants.DATE_FORMAT)
+	return s.baseService.WriteToFile(tasks)
+}
 
-			s.table.Append([]string{strconv.Itoa(task.Id), task.Name, task.Status.String(), createdAt, updatedAt, formatSpendTime, constants.COLUMN_EMPTY})
+// Returns the search documents of the projects and of the tasks of all projects, keyed by the file storing them
+func (s *TaskService) SearchDocuments() (map[string][]search.Document, error) {
+	projectFilePath, projectDocuments, err := s.projectService.SearchDocuments()
+	if err != nil {
+		return nil, err
+	}
 
-			if task.Status == status.TODO {
-				nbOfLeftTasks++
-			}
+	documentsByFile := map[string][]search.Document{projectFilePath: projectDocuments}
+
+	projects, err := s.projectService.FindProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, project := range projects {
+		tasks, err := s.FindTasksOfProject(project.Id)
+		if err != nil {
+			return nil, err
 		}
+
+		documents := []search.Document{}
+		for _, task := range tasks {
+			documents = append(documents, task.SearchDocument())
+		}
+		documentsByFile[taskFilePath(strconv.Itoa(project.Id))] = documents
 	}
 
-	s.table.SetRowLine(true)
-	s.table.SetFooter([]string{"", "", "", "", "", " ", defineTableFooterText(nbOfLeftTasks, len(tasks))})
-	s.table.SetHeaderColor(tablewriter.Colors{tablewriter.Bold},
-		tablewriter.Colors{tablewriter.Bold},
-		tablewriter.Colors{tablewriter.Bold},
-		tablewriter.Colors{tablewriter.Bold},
-		tablewriter.Colors{tablewriter.Bold},
-		tablewriter.Colors{tablewriter.Bold},
-		tablewriter.Colors{tablewriter.Bold},
-	)
-	s.table.SetFooterColor(tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold}, tablewriter.Colors{tablewriter.Bold})
+	return documentsByFile, nil
+}
 
-	s.table.Render()
+func (s *TaskService) DeleteTask(id string, projectId string) error {
+	if err := s.baseService.DeleteItemById(id); err != nil {
+		return err
+	}
+
+	tasks := []Task{}
+	err := s.baseService.ReadFromFile(&tasks)
+	if err != nil {
+		return err
+	}
+
+	if err := s.projectService.UpdateTotalTasksOfProject(projectId, len(tasks)); err != nil {
+		return err
+	}
 
 	return nil
 }
 
-func (s *TaskService) CreateTask(projectID string, name string) error {
+// Finds the tasks of the project the service is bound to with the status. A statusFilter of -1 matches all statuses
+func (s *TaskService) FindTasks(statusFilter status.ItemStatus) ([]Task, error) {
+	tasks := []Task{}
+	if err := s.baseService.ReadFromFile(&tasks); err != nil {
+		return nil, err
+	}
+
+	filteredTasks := []Task{}
+	for _, task := range tasks {
+		if statusFilter == -1 || task.Status == statusFilter {
+			filteredTasks = append(filteredTasks, task)
+		}
+	}
+
+	return filteredTasks, nil
+}
+
+func (s *TaskService) CreateTask(projectID string, name string) (*Task, error) {
 	tasks := []Task{}
 	err := s.baseService.ReadFromFile(&tasks)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	projectId, err := helpers.ValidateIdAndConvertToInt(projectID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	task := Task{
 		Id:             s.baseService.GetNextID(tasks),
 		Name:           name,
 		Status:         status.TODO,
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
+		CreatedAt:      s.clock.Now(),
+		UpdatedAt:      s.clock.Now(),
 		TotalSpentTime: 0,
 		ProjectId:      projectId,
 	}
+	task.StatusHistory = []StatusChange{{Status: status.TODO, ChangedAt: task.CreatedAt}}
 
 	tasks = append(tasks, task)
 
 	if err := s.baseService.WriteToFile(tasks); err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := s.projectService.UpdateTotalTasksOfProject(projectID, len(tasks)); err != nil {
-		return err
+		return nil, err
 	}
 
-	fmt.Println("Task created successfully")
-
-	return nil
+	return &task, nil
 }
@@ -0,0 +1,106 @@
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// STYLES of the text drawn on the screen:
+const (
+	STYLE_RESET     = "\033[0m"
+	STYLE_BOLD      = "\033[1m"
+	STYLE_DIM       = "\033[2m"
+	STYLE_UNDERLINE = "\033[4m"
+	STYLE_REVERSE   = "\033[7m"
+)
+
+// Screen draws full-screen views on the alternate screen of the terminal, redrawing only the rows that have changed
+type Screen struct {
+	out    io.Writer
+	rows   []string
+	Width  int
+	Height int
+}
+
+func NewScreen(out io.Writer) *Screen {
+	return &Screen{out: out}
+}
+
+// Switches to the alternate screen and hides the cursor, so the shell content is restored by Leave
+func (s *Screen) Enter() {
+	fmt.Fprint(s.out, "\033[?1049h\033[?25l\033[H\033[2J")
+	s.Resize()
+}
+
+// Shows the cursor and returns to the content of the terminal before Enter
+func (s *Screen) Leave() {
+	fmt.Fprint(s.out, STYLE_RESET+"\033[?25h\033[?1049l")
+}
+
+// Reads the size of the terminal, and redraws everything on the next Draw if it has changed
+func (s *Screen) Resize() {
+	width, height := Size()
+	if width != s.Width || height != s.Height {
+		s.Width, s.Height = width, height
+		s.Invalidate()
+	}
+}
+
+// Makes the next Draw redraw every row, e.g. after something else has written to the terminal
+func (s *Screen) Invalidate() {
+	s.rows = nil
+	fmt.Fprint(s.out, "\033[H\033[2J")
+}
+
+// Draws the rows from the top of the screen. Rows should be fitted to the width of the screen with Fit
+func (s *Screen) Draw(rows []string) {
+	rows = rows[:min(len(rows), s.Height)]
+
+	var frame strings.Builder
+	for i := 0; i < max(len(rows), len(s.rows)); i++ {
+		row := ""
+		if i < len(rows) {
+			row = rows[i]
+		}
+
+		if i < len(s.rows) && s.rows[i] == row {
+			continue
+		}
+
+		fmt.Fprintf(&frame, "\033[%d;1H\033[2K%s%s", i+1, row, STYLE_RESET)
+	}
+
+	s.rows = rows
+	fmt.Fprint(s.out, frame.String())
+}
+
+// Shows the cursor at the row and column, both starting from 0, e.g. to edit a line there
+func (s *Screen) ShowCursorAt(row int, column int) {
+	fmt.Fprintf(s.out, "\033[%d;%dH\033[2K\033[?25h", row+1, column+1)
+}
+
+// Hides the cursor shown by ShowCursorAt
+func (s *Screen) HideCursor() {
+	fmt.Fprint(s.out, "\033[?25l")
+}
+
+// Truncates or pads the text with spaces to fill exactly the width, measured in terminal columns
+func Fit(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	if runewidth.StringWidth(text) > width {
+		text = runewidth.Truncate(text, width, "…")
+	}
+
+	return runewidth.FillRight(text, width)
+}
+
+// Applies the style to the text
+func Style(style string, text string) string {
+	return style + text + STYLE_RESET
+}
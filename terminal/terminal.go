@@ -0,0 +1,158 @@
+package terminal
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// KEYS that are not characters, read from escape sequences:
+const (
+	KeyUnknown rune = -(iota + 1)
+	KeyEscape
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyHome
+	KeyEnd
+	KeyDelete
+)
+
+// CONTROL KEYS:
+const (
+	KeyCtrlA     rune = 1
+	KeyCtrlB     rune = 2
+	KeyCtrlC     rune = 3
+	KeyCtrlD     rune = 4
+	KeyCtrlE     rune = 5
+	KeyCtrlF     rune = 6
+	KeyCtrlG     rune = 7
+	KeyCtrlH     rune = 8
+	KeyTab       rune = 9
+	KeyLineFeed  rune = 10
+	KeyCtrlK     rune = 11
+	KeyCtrlL     rune = 12
+	KeyEnter     rune = 13
+	KeyCtrlN     rune = 14
+	KeyCtrlP     rune = 16
+	KeyCtrlR     rune = 18
+	KeyCtrlU     rune = 21
+	KeyCtrlW     rune = 23
+	KeyBackspace rune = 127
+)
+
+// Reads a key, decoding the escape sequences of the arrow, home, end and delete keys
+func ReadKey(in *bufio.Reader) (rune, error) {
+	key, _, err := in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+
+	if key != '\033' {
+		return key, nil
+	}
+
+	// Terminals write escape sequences at once, so a lone escape is the escape key
+	if in.Buffered() == 0 {
+		return KeyEscape, nil
+	}
+
+	introducer, err := in.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	if introducer != '[' && introducer != 'O' {
+		return KeyUnknown, nil
+	}
+
+	parameters := ""
+	for {
+		b, err := in.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		if b >= 0x40 && b <= 0x7e {
+			return decodeSequence(parameters, b), nil
+		}
+		parameters += string(b)
+	}
+}
+
+func decodeSequence(parameters string, final byte) rune {
+	switch final {
+	case 'A':
+		return KeyUp
+	case 'B':
+		return KeyDown
+	case 'C':
+		return KeyRight
+	case 'D':
+		return KeyLeft
+	case 'H':
+		return KeyHome
+	case 'F':
+		return KeyEnd
+	case '~':
+		switch parameters {
+		case "1", "7":
+			return KeyHome
+		case "4", "8":
+			return KeyEnd
+		case "3":
+			return KeyDelete
+		}
+	}
+
+	return KeyUnknown
+}
+
+// Switches the terminal to pass the keys one by one, without echoing them or raising signals on Ctrl-C,
+// and returns the function restoring the previous settings. The output processing is kept, so "\n" still starts a new line
+func EnableRawMode() (func(), error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := stty("-icanon", "-echo", "-isig", "-ixon", "min", "1", "time", "0"); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		stty(strings.TrimSpace(state))
+	}, nil
+}
+
+// Returns the number of columns and rows of the terminal, or 80x24 if stty cannot report them
+func Size() (int, int) {
+	size, err := stty("size")
+	if err != nil {
+		return 80, 24
+	}
+
+	fields := strings.Fields(size)
+	if len(fields) != 2 {
+		return 80, 24
+	}
+
+	rows, rowsErr := strconv.Atoi(fields[0])
+	columns, columnsErr := strconv.Atoi(fields[1])
+	if rowsErr != nil || columnsErr != nil || rows <= 0 || columns <= 0 {
+		return 80, 24
+	}
+
+	return columns, rows
+}
+
+func stty(args ...string) (string, error) {
+	sttyCommand := exec.Command("stty", args...)
+	sttyCommand.Stdin = os.Stdin
+	output, err := sttyCommand.Output()
+
+	return string(output), err
+}
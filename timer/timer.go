@@ -0,0 +1,312 @@
+package timer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/MuradIsayev/todo-tracker/clock"
+	"github.com/MuradIsayev/todo-tracker/helpers"
+	"github.com/MuradIsayev/todo-tracker/service"
+	"github.com/MuradIsayev/todo-tracker/session"
+	"github.com/MuradIsayev/todo-tracker/task"
+)
+
+// Describes a pause of the active timer. ResumedAt is nil while the timer is still paused
+type PausedSegment struct {
+	PausedAt  time.Time  `json:"pausedAt"`
+	ResumedAt *time.Time `json:"resumedAt,omitempty"`
+}
+
+// ActiveTimer is the persisted state of the running timer, so it survives the exit of the process
+type ActiveTimer struct {
+	TaskId             int             `json:"taskId"`
+	TaskName           string          `json:"taskName"`
+	ProjectId          int             `json:"projectId"`
+	StartedAt          time.Time       `json:"startedAt"`
+	LastResumedAt      time.Time       `json:"lastResumedAt"`
+	AccumulatedSeconds int             `json:"accumulatedSeconds"`
+	Paused             bool            `json:"paused"`
+	PausedSegments     []PausedSegment `json:"pausedSegments"`
+	LimitSeconds       int             `json:"limitSeconds,omitempty"`
+	Pid                int             `json:"pid,omitempty"`
+}
+
+// Returns the focused (not paused) seconds of the timer, capped at the limit of a countdown
+func (t *ActiveTimer) ElapsedSeconds(now time.Time) int {
+	elapsedSeconds := t.AccumulatedSeconds
+	if !t.Paused {
+		elapsedSeconds += int(now.Sub(t.LastResumedAt).Seconds())
+	}
+
+	if t.LimitSeconds > 0 && elapsedSeconds > t.LimitSeconds {
+		return t.LimitSeconds
+	}
+
+	return elapsedSeconds
+}
+
+// Returns the focused intervals of the timer, from its start or resumes to its pauses or now.
+// The intervals of a countdown end once its limit is reached
+func (t *ActiveTimer) FocusedSegments(now time.Time) []session.Segment {
+	segments := []session.Segment{}
+	startedAt := t.StartedAt
+	for _, pausedSegment := range t.PausedSegments {
+		segments = append(segments, session.Segment{StartedAt: startedAt, EndedAt: pausedSegment.PausedAt})
+		if pausedSegment.ResumedAt == nil {
+			break
+		}
+		startedAt = *pausedSegment.ResumedAt
+	}
+
+	if !t.Paused {
+		segments = append(segments, session.Segment{StartedAt: startedAt, EndedAt: now})
+	}
+
+	if t.LimitSeconds <= 0 {
+		return segments
+	}
+
+	remaining := time.Duration(t.LimitSeconds) * time.Second
+	for i, segment := range segments {
+		length := segment.EndedAt.Sub(segment.StartedAt)
+		if length > remaining {
+			segments[i].EndedAt = segment.StartedAt.Add(remaining)
+			return segments[:i+1]
+		}
+		remaining -= length
+	}
+
+	return segments
+}
+
+// Checks if the timer was owned by an interactive session that is no longer running
+func (t *ActiveTimer) IsStale() bool {
+	return t.Pid != 0 && t.Pid != os.Getpid() && !helpers.IsProcessRunning(t.Pid)
+}
+
+// Checks if the timer was started for the task by the session with the pid
+func (t *ActiveTimer) IsOwnedBy(pid int, taskId int) bool {
+	return t.Pid == pid && t.TaskId == taskId
+}
+
+// TimerStatusRecord is the machine-readable representation of the active timer
+type TimerStatusRecord struct {
+	TaskId         int       `json:"taskId"`
+	TaskName       string    `json:"taskName"`
+	ProjectId      int       `json:"projectId"`
+	State          string    `json:"state"`
+	StartedAt      time.Time `json:"startedAt"`
+	ElapsedSeconds int       `json:"elapsedSeconds"`
+	LimitSeconds   int       `json:"limitSeconds"`
+}
+
+func NewTimerStatusRecord(activeTimer *ActiveTimer, now time.Time) TimerStatusRecord {
+	state := "running"
+	if activeTimer.Paused {
+		state = "paused"
+	}
+
+	return TimerStatusRecord{
+		TaskId:         activeTimer.TaskId,
+		TaskName:       activeTimer.TaskName,
+		ProjectId:      activeTimer.ProjectId,
+		State:          state,
+		StartedAt:      activeTimer.StartedAt,
+		ElapsedSeconds: activeTimer.ElapsedSeconds(now),
+		LimitSeconds:   activeTimer.LimitSeconds,
+	}
+}
+
+type TimerService struct {
+	FilePath                  string
+	taskService               *task.TaskService
+	circularDependencyManager *service.Manager
+	clock                     clock.Clock
+}
+
+type TimerManager interface {
+	Load() (*ActiveTimer, error)
+	Start(task *task.Task, pid int, limitSeconds int) (*ActiveTimer, error)
+	Pause() (*ActiveTimer, error)
+	Resume() (*ActiveTimer, error)
+	Adjust(seconds int) (*ActiveTimer, error)
+	DiscardOwned(pid int, taskId int) error
+}
+
+func NewTimerService(filePath string, taskService *task.TaskService, circularDependencyManager *service.Manager, clock clock.Clock) *TimerService {
+	return &TimerService{
+		FilePath:                  filePath,
+		taskService:               taskService,
+		circularDependencyManager: circularDependencyManager,
+		clock:                     clock,
+	}
+}
+
+// Reads the active timer from the file. Returns nil if there is no active timer
+func (s *TimerService) Load() (*ActiveTimer, error) {
+	fileContent, err := os.ReadFile(s.FilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("cannot read file: %v", err)
+	}
+
+	if len(fileContent) == 0 {
+		return nil, nil
+	}
+
+	activeTimer := &ActiveTimer{}
+	if err := json.Unmarshal(fileContent, activeTimer); err != nil {
+		return nil, fmt.Errorf("cannot convert JSON to struct: %v", err)
+	}
+
+	return activeTimer, nil
+}
+
+func (s *TimerService) save(activeTimer *ActiveTimer) error {
+	jsonData, err := json.Marshal(activeTimer)
+	if err != nil {
+		return fmt.Errorf("cannot convert struct to JSON: %v", err)
+	}
+
+	if err := os.WriteFile(s.FilePath, jsonData, 0644); err != nil {
+		return fmt.Errorf("cannot write to file: %v", err)
+	}
+
+	return nil
+}
+
+func (s *TimerService) loadActive() (*ActiveTimer, error) {
+	activeTimer, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if activeTimer == nil {
+		return nil, errors.New("no timer is running")
+	}
+
+	return activeTimer, nil
+}
+
+// Starts a timer for the task. The pid identifies the interactive session owning the timer (0 if detached),
+// limitSeconds caps the recorded time of a countdown (0 if unlimited)
+func (s *TimerService) Start(task *task.Task, pid int, limitSeconds int) (*ActiveTimer, error) {
+	activeTimer, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if activeTimer != nil {
+		return nil, fmt.Errorf("a timer is already running for the task \"%s\"", activeTimer.TaskName)
+	}
+
+	now := s.clock.Now()
+	activeTimer = &ActiveTimer{
+		TaskId:        task.Id,
+		TaskName:      task.Name,
+		ProjectId:     task.ProjectId,
+		StartedAt:     now,
+		LastResumedAt: now,
+		LimitSeconds:  limitSeconds,
+		Pid:           pid,
+	}
+
+	return activeTimer, s.save(activeTimer)
+}
+
+// Pauses the active timer
+func (s *TimerService) Pause() (*ActiveTimer, error) {
+	activeTimer, err := s.loadActive()
+	if err != nil {
+		return nil, err
+	}
+
+	if activeTimer.Paused {
+		return nil, errors.New("timer is already paused")
+	}
+
+	now := s.clock.Now()
+	activeTimer.AccumulatedSeconds = activeTimer.ElapsedSeconds(now)
+	activeTimer.Paused = true
+	activeTimer.PausedSegments = append(activeTimer.PausedSegments, PausedSegment{PausedAt: now})
+
+	return activeTimer, s.save(activeTimer)
+}
+
+// Resumes the paused timer
+func (s *TimerService) Resume() (*ActiveTimer, error) {
+	activeTimer, err := s.loadActive()
+	if err != nil {
+		return nil, err
+	}
+
+	if !activeTimer.Paused {
+		return nil, errors.New("timer is not paused")
+	}
+
+	now := s.clock.Now()
+	activeTimer.Paused = false
+	activeTimer.LastResumedAt = now
+	if len(activeTimer.PausedSegments) > 0 {
+		activeTimer.PausedSegments[len(activeTimer.PausedSegments)-1].ResumedAt = &now
+	}
+
+	return activeTimer, s.save(activeTimer)
+}
+
+// Adds the given seconds (negative to subtract) to the focused time of the active timer
+func (s *TimerService) Adjust(seconds int) (*ActiveTimer, error) {
+	activeTimer, err := s.loadActive()
+	if err != nil {
+		return nil, err
+	}
+
+	activeTimer.AccumulatedSeconds = max(activeTimer.AccumulatedSeconds+seconds, 0)
+
+	return activeTimer, s.save(activeTimer)
+}
+
+// Stops the active timer and saves the focused time to the task and its project
+func (s *TimerService) Stop() (*ActiveTimer, int, error) {
+	activeTimer, err := s.loadActive()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := s.clock.Now()
+	elapsedSeconds := activeTimer.ElapsedSeconds(now)
+
+	// The task is updated in its own project, without rebinding the task service shared with the running command
+	projectManager := s.circularDependencyManager.WithTaskService(s.taskService.ForProject(strconv.Itoa(activeTimer.ProjectId)))
+	if err := projectManager.UpdateTaskAndProjectTimers(activeTimer.TaskId, activeTimer.ProjectId, elapsedSeconds, activeTimer.FocusedSegments(now)); err != nil {
+		return nil, 0, err
+	}
+
+	return activeTimer, elapsedSeconds, helpers.RemoveFileByFilePath(s.FilePath)
+}
+
+// Removes the active timer without saving the focused time
+func (s *TimerService) Discard() (*ActiveTimer, error) {
+	activeTimer, err := s.loadActive()
+	if err != nil {
+		return nil, err
+	}
+
+	return activeTimer, helpers.RemoveFileByFilePath(s.FilePath)
+}
+
+// Removes the active timer if the session with the pid has started it for the task, so the timer of another session is kept
+func (s *TimerService) DiscardOwned(pid int, taskId int) error {
+	activeTimer, err := s.Load()
+	if err != nil || activeTimer == nil || !activeTimer.IsOwnedBy(pid, taskId) {
+		return err
+	}
+
+	return helpers.RemoveFileByFilePath(s.FilePath)
+}
@@ -0,0 +1,554 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/MuradIsayev/todo-tracker/board"
+	"github.com/MuradIsayev/todo-tracker/clock"
+	"github.com/MuradIsayev/todo-tracker/config"
+	"github.com/MuradIsayev/todo-tracker/countdown"
+	"github.com/MuradIsayev/todo-tracker/editor"
+	"github.com/MuradIsayev/todo-tracker/helpers"
+	"github.com/MuradIsayev/todo-tracker/project"
+	"github.com/MuradIsayev/todo-tracker/task"
+	"github.com/MuradIsayev/todo-tracker/terminal"
+)
+
+// Smallest terminal the panes fit in
+const (
+	minWidth  = 60
+	minHeight = 10
+)
+
+const (
+	projectsHelp = "↑↓ select  Enter open  a add  e rename  Tab board  q quit"
+	boardHelp    = "←→↑↓ move  a add  e rename  < > status  d delete  t timer  Tab projects  q quit"
+)
+
+// Describes the pane that receives the keys
+type pane int
+
+const (
+	projectsPane pane = iota
+	boardPane
+)
+
+// App is the full-screen terminal UI: the list of projects next to a kanban board of the tasks of the selected project
+type App struct {
+	projectService *project.ProjectService
+	taskService    *task.TaskService
+	newCountdown   func(taskService *task.TaskService, taskId string) (*countdown.CountdownService, *task.Task, error)
+	boardColumns   []config.BoardColumnConfig
+	clock          clock.Clock
+	in             *bufio.Reader
+	screen         *terminal.Screen
+	lineEditor     *editor.Editor
+
+	projects     []project.Project
+	projectTasks *task.TaskService // Copy of the task service bound to the selected project
+	board        *board.Board      // Tasks of the selected project in the configured columns
+	focus        pane
+	projectIndex int
+	column       int
+	rows         []int // Selected row of each column
+	message      string
+}
+
+func NewApp(
+	projectService *project.ProjectService,
+	taskService *task.TaskService,
+	newCountdown func(taskService *task.TaskService, taskId string) (*countdown.CountdownService, *task.Task, error),
+	boardColumns []config.BoardColumnConfig,
+	clock clock.Clock,
+	in *bufio.Reader,
+	out io.Writer,
+) *App {
+	return &App{
+		projectService: projectService,
+		taskService:    taskService,
+		newCountdown:   newCountdown,
+		boardColumns:   boardColumns,
+		clock:          clock,
+		in:             in,
+		screen:         terminal.NewScreen(out),
+		lineEditor:     editor.NewEditor(in, out, true),
+		board:          &board.Board{},
+	}
+}
+
+// Runs the UI until it is quit, starting on the project (0 selects the first project). The input and output must be the terminal
+func (a *App) Run(projectId int) error {
+	restore, err := terminal.EnableRawMode()
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	a.screen.Enter()
+	defer a.screen.Leave()
+
+	if err := a.loadProjects(); err != nil {
+		return err
+	}
+
+	for i, p := range a.projects {
+		if p.Id == projectId {
+			a.projectIndex = i
+			a.focus = boardPane
+		}
+	}
+
+	a.showError(a.loadTasks())
+
+	for {
+		a.draw()
+
+		key, err := terminal.ReadKey(a.in)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		a.message = ""
+
+		switch key {
+		case 'q', terminal.KeyCtrlC, terminal.KeyCtrlD:
+			return nil
+		case terminal.KeyTab:
+			a.switchFocus()
+		case terminal.KeyCtrlL:
+			a.screen.Invalidate()
+		default:
+			if a.focus == projectsPane {
+				a.showError(a.handleProjectsKey(key))
+			} else {
+				a.showError(a.handleBoardKey(key))
+			}
+		}
+	}
+}
+
+func (a *App) showError(err error) {
+	if err != nil {
+		a.message = "Error: " + err.Error()
+	}
+}
+
+func (a *App) switchFocus() {
+	if a.focus == projectsPane && len(a.projects) > 0 {
+		a.focus = boardPane
+	} else {
+		a.focus = projectsPane
+	}
+}
+
+func (a *App) handleProjectsKey(key rune) error {
+	switch key {
+	case terminal.KeyUp, 'k':
+		if a.projectIndex > 0 {
+			a.projectIndex--
+			return a.selectProject()
+		}
+	case terminal.KeyDown, 'j':
+		if a.projectIndex < len(a.projects)-1 {
+			a.projectIndex++
+			return a.selectProject()
+		}
+	case terminal.KeyEnter, terminal.KeyLineFeed, terminal.KeyRight, 'l':
+		a.switchFocus()
+	case 'a':
+		name, ok := a.prompt("New project: ", "")
+		if !ok {
+			return nil
+		}
+
+		createdProject, err := a.projectService.CreateProject(name)
+		if err != nil {
+			return err
+		}
+
+		if err := a.loadProjects(); err != nil {
+			return err
+		}
+		a.projectIndex = len(a.projects) - 1
+		a.message = fmt.Sprintf("Project \"%s\" created", createdProject.Name)
+
+		return a.selectProject()
+	case 'e':
+		selected := a.selectedProject()
+		if selected == nil {
+			return nil
+		}
+
+		name, ok := a.prompt("Rename project: ", selected.Name)
+		if !ok {
+			return nil
+		}
+
+		if err := a.projectService.UpdateProjectName(strconv.Itoa(selected.Id), name); err != nil {
+			return err
+		}
+
+		return a.loadProjects()
+	}
+
+	return nil
+}
+
+func (a *App) handleBoardKey(key rune) error {
+	selectedProject := a.selectedProject()
+	if selectedProject == nil || len(a.board.Columns) == 0 {
+		return nil
+	}
+	projectId := strconv.Itoa(selectedProject.Id)
+	selectedTask := a.selectedTask()
+
+	switch key {
+	case terminal.KeyEscape:
+		a.focus = projectsPane
+	case terminal.KeyLeft, 'h':
+		if a.column == 0 {
+			a.focus = projectsPane
+		} else {
+			a.column--
+		}
+	case terminal.KeyRight, 'l':
+		a.column = min(a.column+1, len(a.board.Columns)-1)
+	case terminal.KeyUp, 'k':
+		a.rows[a.column] = max(a.rows[a.column]-1, 0)
+	case terminal.KeyDown, 'j':
+		a.rows[a.column] = min(a.rows[a.column]+1, max(len(a.board.Columns[a.column].Tasks)-1, 0))
+	case 'a':
+		name, ok := a.prompt("New task: ", "")
+		if !ok {
+			return nil
+		}
+
+		createdTask, err := a.projectTasks.CreateTask(projectId, name)
+		if err != nil {
+			return err
+		}
+
+		a.message = fmt.Sprintf("Task \"%s\" created", createdTask.Name)
+
+		return a.reload(createdTask.Id)
+	case 'e':
+		if selectedTask == nil {
+			return nil
+		}
+
+		name, ok := a.prompt("Rename task: ", selectedTask.Name)
+		if !ok {
+			return nil
+		}
+
+		if err := a.projectTasks.UpdateTaskName(strconv.Itoa(selectedTask.Id), name); err != nil {
+			return err
+		}
+
+		return a.reload(selectedTask.Id)
+	case '<', '>':
+		if selectedTask == nil {
+			return nil
+		}
+
+		step := 1
+		if key == '<' {
+			step = -1
+		}
+
+		// Columns of the same status, e.g. split by a query, are skipped since the task would not move into them
+		target := a.column + step
+		for target >= 0 && target < len(a.board.Columns) && a.board.Columns[target].Status == selectedTask.Status {
+			target += step
+		}
+		if target < 0 || target >= len(a.board.Columns) {
+			return nil
+		}
+
+		if err := a.projectTasks.UpdateTaskStatus(strconv.Itoa(selectedTask.Id), a.board.Columns[target].Status); err != nil {
+			return err
+		}
+
+		return a.reload(selectedTask.Id)
+	case 'd':
+		if selectedTask == nil || !a.confirm(fmt.Sprintf("Delete the task \"%s\"? (y/n)", selectedTask.Name)) {
+			return nil
+		}
+
+		if err := a.projectTasks.DeleteTask(strconv.Itoa(selectedTask.Id), projectId); err != nil {
+			return err
+		}
+
+		a.message = fmt.Sprintf("Task \"%s\" deleted", selectedTask.Name)
+
+		return a.reload(0)
+	case 't':
+		if selectedTask == nil {
+			return nil
+		}
+
+		return a.startCountdown(selectedTask)
+	}
+
+	return nil
+}
+
+// Asks for the duration and runs the countdown widget for the task, or a stopwatch for a duration of 0
+func (a *App) startCountdown(selectedTask *task.Task) error {
+	value, ok := a.prompt("Countdown minutes (0 for a stopwatch): ", "25")
+	if !ok {
+		return nil
+	}
+
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes < 0 {
+		return fmt.Errorf("invalid number of minutes %s", value)
+	}
+
+	countdownService, timedTask, err := a.newCountdown(a.projectTasks, strconv.Itoa(selectedTask.Id))
+	if err != nil {
+		return err
+	}
+
+	title := fmt.Sprintf("%d-minute countdown", minutes)
+	if minutes == 0 {
+		title = "Stopwatch"
+	}
+
+	widget := &countdownWidget{countdownService: countdownService, title: title, screen: a.screen}
+	a.message, err = widget.run(a.in, func() error {
+		if minutes == 0 {
+			return countdownService.StartStopwatch(timedTask)
+		}
+		return countdownService.StartCountdown(timedTask, minutes)
+	})
+	a.screen.Invalidate()
+	if err != nil {
+		return err
+	}
+
+	// The focused time has been saved to the task and its project
+	if err := a.loadProjects(); err != nil {
+		return err
+	}
+
+	return a.reload(selectedTask.Id)
+}
+
+// Edits a line at the bottom of the screen, starting with the text. Returns false if the line is cancelled or empty
+func (a *App) prompt(label string, text string) (string, bool) {
+	a.screen.ShowCursorAt(a.screen.Height-2, 0)
+	value, err := a.lineEditor.EditLine(label, text)
+	a.screen.HideCursor()
+	a.screen.Invalidate()
+
+	value = strings.TrimSpace(value)
+
+	return value, err == nil && value != ""
+}
+
+// Asks the question on the message line and waits for a key, y confirms
+func (a *App) confirm(question string) bool {
+	a.message = question
+	a.draw()
+	a.message = ""
+
+	key, err := terminal.ReadKey(a.in)
+
+	return err == nil && (key == 'y' || key == 'Y')
+}
+
+func (a *App) loadProjects() error {
+	projects, err := a.projectService.FindProjects()
+	if err != nil {
+		return err
+	}
+
+	a.projects = projects
+	a.projectIndex = max(min(a.projectIndex, len(projects)-1), 0)
+	if len(projects) == 0 {
+		a.focus = projectsPane
+	}
+
+	return nil
+}
+
+// Shows the tasks of the newly selected project from the first column
+func (a *App) selectProject() error {
+	a.column = 0
+	for i := range a.rows {
+		a.rows[i] = 0
+	}
+
+	return a.loadTasks()
+}
+
+// Binds a copy of the task service to the selected project and builds the board of its tasks with the configured columns.
+// The shared task service stays bound to the project of the command
+func (a *App) loadTasks() error {
+	var tasks []task.Task
+	if selected := a.selectedProject(); selected != nil {
+		a.projectTasks = a.taskService.ForProject(strconv.Itoa(selected.Id))
+
+		var err error
+		tasks, err = a.projectTasks.FindTasks(-1)
+		if err != nil {
+			return err
+		}
+	}
+
+	taskBoard, err := board.BuildBoard("", tasks, a.boardColumns, a.clock.Now())
+	if err != nil {
+		return err
+	}
+	a.board = taskBoard
+
+	if len(a.rows) != len(a.board.Columns) {
+		a.rows = make([]int, len(a.board.Columns))
+	}
+
+	for column := range a.rows {
+		a.rows[column] = max(min(a.rows[column], len(a.board.Columns[column].Tasks)-1), 0)
+	}
+
+	return nil
+}
+
+// Reloads the tasks and selects the task, wherever its status has moved it (0 keeps the current position)
+func (a *App) reload(taskId int) error {
+	if err := a.loadTasks(); err != nil {
+		return err
+	}
+
+	for column, boardColumn := range a.board.Columns {
+		for row, t := range boardColumn.Tasks {
+			if t.Id == taskId {
+				a.column, a.rows[column] = column, row
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a *App) selectedProject() *project.Project {
+	if a.projectIndex >= len(a.projects) {
+		return nil
+	}
+
+	return &a.projects[a.projectIndex]
+}
+
+func (a *App) selectedTask() *task.Task {
+	if a.column >= len(a.board.Columns) || a.rows[a.column] >= len(a.board.Columns[a.column].Tasks) {
+		return nil
+	}
+
+	return &a.board.Columns[a.column].Tasks[a.rows[a.column]]
+}
+
+// Draws the title bar, the project pane, the board, the message line and the keys of the focused pane
+func (a *App) draw() {
+	a.screen.Resize()
+	width, height := a.screen.Width, a.screen.Height
+
+	rows := make([]string, height)
+	if width < minWidth || height < minHeight {
+		rows[0] = terminal.Fit(fmt.Sprintf("The terminal is too small, it needs at least %dx%d", minWidth, minHeight), width)
+		a.screen.Draw(rows)
+		return
+	}
+
+	title := " todo-tracker"
+	if selected := a.selectedProject(); selected != nil {
+		title += fmt.Sprintf(" | %s | %s | %s spent", selected.Name, selected.Status, strings.TrimSpace(helpers.FormatSpendTime(selected.TotalSpentTime)))
+	}
+	rows[0] = terminal.Style(terminal.STYLE_REVERSE, terminal.Fit(title, width))
+
+	projectsWidth := min(30, width/4)
+	nbOfColumns := max(len(a.board.Columns), 1)
+	columnWidth := (width - projectsWidth - nbOfColumns) / nbOfColumns
+	listHeight := height - 5 // Title, pane headers, message and help rows
+
+	// Pane headers
+	header := a.heading(" PROJECTS", projectsWidth, a.focus == projectsPane)
+	for column, boardColumn := range a.board.Columns {
+		header += "│" + a.heading(" "+boardColumn.Header(), columnWidth, a.focus == boardPane && a.column == column)
+	}
+	rows[1] = header
+
+	projectsStart := scrollStart(a.projectIndex, len(a.projects), listHeight)
+	columnStarts := make([]int, len(a.board.Columns))
+	for column, boardColumn := range a.board.Columns {
+		columnStarts[column] = scrollStart(a.rows[column], len(boardColumn.Tasks), listHeight)
+	}
+
+	for line := 0; line < listHeight; line++ {
+		row := strings.Repeat(" ", projectsWidth)
+		if index := projectsStart + line; index < len(a.projects) {
+			p := a.projects[index]
+			row = a.item(fmt.Sprintf(" %d %s", p.Id, p.Name), projectsWidth, index == a.projectIndex, a.focus == projectsPane)
+		}
+
+		for column, boardColumn := range a.board.Columns {
+			tasks := boardColumn.Tasks
+			cell := strings.Repeat(" ", columnWidth)
+			if index := columnStarts[column] + line; index < len(tasks) {
+				selected := column == a.column && index == a.rows[column]
+				cell = a.item(fmt.Sprintf(" #%d %s", tasks[index].Id, tasks[index].Name), columnWidth, selected, a.focus == boardPane)
+			}
+			row += "│" + cell
+		}
+
+		rows[2+line] = row
+	}
+
+	rows[height-3] = strings.Repeat("─", width)
+	rows[height-2] = terminal.Fit(a.message, width)
+
+	help := projectsHelp
+	if a.focus == boardPane {
+		help = boardHelp
+	}
+	rows[height-1] = terminal.Style(terminal.STYLE_DIM, terminal.Fit(" "+help, width))
+
+	a.screen.Draw(rows)
+}
+
+// Draws the header of a pane or column, highlighted when it has the focus
+func (a *App) heading(text string, width int, focused bool) string {
+	if focused {
+		return terminal.Style(terminal.STYLE_BOLD+terminal.STYLE_REVERSE, terminal.Fit(text, width))
+	}
+
+	return terminal.Style(terminal.STYLE_BOLD, terminal.Fit(text, width))
+}
+
+// Draws an entry of a list: the selected entry is highlighted in the focused pane, and underlined elsewhere
+func (a *App) item(text string, width int, selected bool, focused bool) string {
+	switch {
+	case selected && focused:
+		return terminal.Style(terminal.STYLE_REVERSE, terminal.Fit(text, width))
+	case selected:
+		return terminal.Style(terminal.STYLE_UNDERLINE, terminal.Fit(text, width))
+	default:
+		return terminal.Fit(text, width)
+	}
+}
+
+// Returns the first entry shown in a list of the height, so the selected entry stays visible
+func scrollStart(selected int, total int, height int) int {
+	if total <= height {
+		return 0
+	}
+
+	return min(max(selected-height+1, 0), total-height)
+}
@@ -0,0 +1,152 @@
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/MuradIsayev/todo-tracker/constants"
+	"github.com/MuradIsayev/todo-tracker/countdown"
+	"github.com/MuradIsayev/todo-tracker/terminal"
+	"github.com/mattn/go-runewidth"
+)
+
+const countdownHelp = "p pause  r resume  s stop and save  e exit without saving  k/d keep/discard the idle time"
+
+// Returned by RunCountdown when the terminal cannot be switched to raw mode, the caller can fall back to the line mode
+var ErrRawMode = errors.New("cannot switch the terminal to raw mode")
+
+// countdownWidget shows the live display of a countdown, stopwatch or pomodoro session and reads its controls key by key
+type countdownWidget struct {
+	countdownService *countdown.CountdownService
+	title            string
+	screen           *terminal.Screen
+
+	mutex   sync.Mutex // Guards the fields below, updated by the display goroutine
+	display string
+	message string
+}
+
+// Runs the timer full-screen until it ends, and returns its last message and the error of the timer.
+// The terminal is restored when the widget is left, so it can be used from the line-based modes
+func RunCountdown(in *bufio.Reader, out io.Writer, countdownService *countdown.CountdownService, title string, startTimer func() error) (string, error) {
+	restore, err := terminal.EnableRawMode()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrRawMode, err)
+	}
+	defer restore()
+
+	screen := terminal.NewScreen(out)
+	screen.Enter()
+	defer screen.Leave()
+
+	widget := &countdownWidget{countdownService: countdownService, title: title, screen: screen}
+
+	return widget.run(in, startTimer)
+}
+
+// Starts the timer and handles the keys until the timer ends. Returns the last message and the error of the timer
+func (w *countdownWidget) run(in *bufio.Reader, startTimer func() error) (string, error) {
+	var wg sync.WaitGroup
+	stop := make(chan bool)
+
+	timerErr := make(chan error, 1)
+	go func() {
+		timerErr <- startTimer()
+	}()
+
+	// Redraw on every display update, until the widget is left
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case display := <-w.countdownService.DisplayChan:
+				w.mutex.Lock()
+				w.display = display
+				w.mutex.Unlock()
+				w.draw()
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	// Called once the timer has ended
+	lastMessage := func() (string, error) {
+		close(stop)
+		wg.Wait() // The last display update is sent before the timer ends, so it has been received
+
+		if w.message != "" {
+			return w.message, <-timerErr
+		}
+
+		return w.display, <-timerErr
+	}
+
+	w.draw()
+
+	for {
+		key, err := terminal.ReadKey(in)
+		if err != nil {
+			w.countdownService.Control(constants.TIMER_EXIT)
+			return lastMessage()
+		}
+
+		if w.countdownService.IsDone() {
+			return lastMessage()
+		}
+
+		if key == terminal.KeyCtrlC {
+			w.setMessage("Type (e)xit to leave the timer without saving the time.")
+			continue
+		}
+
+		ended, message := w.countdownService.Control(string(unicode.ToLower(key)))
+		w.setMessage(message)
+		if ended {
+			return lastMessage()
+		}
+	}
+}
+
+func (w *countdownWidget) setMessage(message string) {
+	w.mutex.Lock()
+	w.message = message
+	w.mutex.Unlock()
+	w.draw()
+}
+
+// Draws the title bar, the display in the middle of the screen with the message below it, and the controls at the bottom
+func (w *countdownWidget) draw() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.screen.Resize()
+	width, height := w.screen.Width, w.screen.Height
+
+	rows := make([]string, height)
+	rows[0] = terminal.Style(terminal.STYLE_REVERSE, terminal.Fit(" "+w.title, width))
+
+	middle := max(height/2-1, 1)
+	rows[middle] = terminal.Style(terminal.STYLE_BOLD, center(w.display, width))
+	if middle+2 < height-1 {
+		rows[middle+2] = center(w.message, width)
+	}
+
+	rows[height-1] = terminal.Style(terminal.STYLE_DIM, terminal.Fit(" "+countdownHelp, width))
+
+	w.screen.Draw(rows)
+}
+
+// Centers the text in the width, truncating it if it does not fit
+func center(text string, width int) string {
+	text = strings.TrimSpace(text)
+	padding := max((width-runewidth.StringWidth(text))/2, 0)
+
+	return terminal.Fit(strings.Repeat(" ", padding)+text, width)
+}